@@ -0,0 +1,89 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/brojonat/temporal-examples/auction/temporal"
+	"github.com/brojonat/temporal-examples/convenience"
+	"github.com/brojonat/temporal-examples/worker"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+)
+
+// register a bidder with a credit limit
+func handleRegisterBidder(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.RunBidderWFRequest
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		if err = payload.Validate(); err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		wopts := client.StartWorkflowOptions{
+			ID:        temporal.BidderWorkflowID(payload.Bidder),
+			TaskQueue: worker.TaskQueue,
+
+			WorkflowExecutionErrorWhenAlreadyStarted: true,
+		}
+		_, err = tc.ExecuteWorkflow(r.Context(), wopts, temporal.RunBidderWF, payload)
+		if err != nil {
+			var started *serviceerror.WorkflowExecutionAlreadyStarted
+			if errors.As(err, &started) {
+				convenience.WriteBadRequestError(w, fmt.Errorf("bidder %s is already registered", payload.Bidder))
+				return
+			}
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		convenience.WriteOK(w)
+	}
+}
+
+// adjust a registered bidder's credit limit
+func handleSetBidderLimit(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		bidder := r.URL.Query().Get("bidder")
+		limit, err := temporal.ParseMoney(r.URL.Query().Get("limit"))
+		if err != nil {
+			convenience.WriteBadRequestError(w, fmt.Errorf("bad limit: %w", err))
+			return
+		}
+		err = updateAuction(r.Context(), tc, temporal.BidderWorkflowID(bidder), temporal.UpdateTypeSetLimit, nil, limit)
+		if err != nil {
+			writeUpdateError(l, w, err)
+			return
+		}
+		convenience.WriteOK(w)
+	}
+}
+
+// query a registered bidder's credit
+func handleGetBidder(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := temporal.BidderWorkflowID(r.URL.Query().Get("bidder"))
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeBidder)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		var result temporal.BidderState
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		msg := fmt.Sprintf(
+			"credit limit %s; %s committed across %d auctions, %s available",
+			result.CreditLimit, result.Committed, len(result.Commitments), result.Available)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
+	}
+}
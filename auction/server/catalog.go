@@ -0,0 +1,96 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/brojonat/temporal-examples/auction/temporal"
+	"github.com/brojonat/temporal-examples/convenience"
+	"github.com/brojonat/temporal-examples/worker"
+	"go.temporal.io/sdk/client"
+)
+
+func idFromCatalog(c string) string {
+	return fmt.Sprintf("catalog: %s", c)
+}
+
+// start a catalog of auctions
+func handleCatalogStart(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.RunCatalogWFRequest
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		if err = payload.Validate(); err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		wopts := client.StartWorkflowOptions{
+			ID:        idFromCatalog(payload.Catalog),
+			TaskQueue: worker.TaskQueue,
+		}
+		_, err = tc.ExecuteWorkflow(r.Context(), wopts, temporal.RunCatalogWF, payload)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		convenience.WriteOK(w)
+	}
+}
+
+// query the catalog workflow for its running totals
+func handleCatalogGetState(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id := idFromCatalog(r.URL.Query().Get("catalog"))
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeCatalog)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		var result temporal.CatalogReport
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		msg := fmt.Sprintf(
+			"%d lots: %d open, %d sold, %d unsold, %d failed; gross %s",
+			result.Lots, result.Open, result.Sold, result.Unsold, result.Failed, result.Gross)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
+	}
+}
+
+// handle the catalog report; every lot's result is stored as if it had been
+// sent to the auction webhook
+func handleCatalogReport(l *slog.Logger, results *resultStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.CatalogReport
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+
+		l.Info(
+			"got catalog report",
+			"catalog", payload.Catalog,
+			"lots", payload.Lots,
+			"sold", payload.Sold,
+			"unsold", payload.Unsold,
+			"failed", payload.Failed,
+			"errors", payload.Errors,
+			"gross", payload.Gross,
+			"closed_at", payload.ClosedAt,
+		)
+		for _, result := range payload.Results {
+			results.Put(result)
+		}
+		convenience.WriteOK(w)
+	}
+}
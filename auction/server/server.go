@@ -2,17 +2,47 @@ package server
 
 import (
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
 	"net/http"
+	"strconv"
+	"sync"
+	"time"
 
 	"github.com/brojonat/temporal-examples/auction/temporal"
 	"github.com/brojonat/temporal-examples/convenience"
 	"github.com/brojonat/temporal-examples/worker"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	sdktemporal "go.temporal.io/sdk/temporal"
 )
 
+// resultStore keeps the most recent result received for each item
+type resultStore struct {
+	mu      sync.RWMutex
+	results map[string]temporal.AuctionResult
+}
+
+func newResultStore() *resultStore {
+	return &resultStore{results: make(map[string]temporal.AuctionResult)}
+}
+
+func (s *resultStore) Put(r temporal.AuctionResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.results[r.Item] = r
+}
+
+func (s *resultStore) Get(item string) (temporal.AuctionResult, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	r, ok := s.results[item]
+	return r, ok
+}
+
 // run an http server with endpoints for the auction workflow
 func RunHTTPServer(
 	ctx context.Context,
@@ -30,11 +60,28 @@ func RunHTTPServer(
 	}
 	defer tc.Close()
 
+	results := newResultStore()
 	mux := http.NewServeMux()
 	mux.Handle("POST /start", handleStart(l, tc))
 	mux.Handle("POST /bid", handleBid(l, tc))
 	mux.Handle("GET /get-state", handleGetState(l, tc))
-	mux.Handle("POST /webhook", handleResult(l, tc))
+	mux.Handle("GET /stream", handleStream(l, tc))
+	mux.Handle("GET /bids", handleGetBids(l, tc))
+	mux.Handle("GET /leaderboard", handleGetLeaderboard(l, tc))
+	mux.Handle("GET /timeline", handleGetTimeline(l, tc))
+	mux.Handle("POST /cancel", handleCancel(l, tc))
+	mux.Handle("POST /extend", handleExtend(l, tc))
+	mux.Handle("POST /close", handleClose(l, tc))
+	mux.Handle("GET /result", handleGetResult(l, results))
+	mux.Handle("POST /webhook", handleResult(l, results))
+	mux.Handle("POST /webhook/cancel", handleCancelled(l))
+	mux.Handle("POST /webhook/outbid", handleOutbid(l))
+	mux.Handle("POST /catalog/start", handleCatalogStart(l, tc))
+	mux.Handle("GET /catalog/get-state", handleCatalogGetState(l, tc))
+	mux.Handle("POST /webhook/catalog", handleCatalogReport(l, results))
+	mux.Handle("POST /bidders/register", handleRegisterBidder(l, tc))
+	mux.Handle("POST /bidders/limit", handleSetBidderLimit(l, tc))
+	mux.Handle("GET /bidders/get-state", handleGetBidder(l, tc))
 
 	listenAddr := fmt.Sprintf(":%s", port)
 	l.Info("listening", "port", listenAddr)
@@ -51,6 +98,10 @@ func handleStart(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			convenience.WriteInternalError(l, w, err)
 			return
 		}
+		if err = payload.Validate(); err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
 		wopts := client.StartWorkflowOptions{
 			ID:        payload.Item,
 			TaskQueue: worker.TaskQueue,
@@ -79,13 +130,205 @@ func handleGetState(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			convenience.WriteInternalError(l, w, err)
 			return
 		}
-		msg := fmt.Sprintf("top bid by %s for %f", result.Bidder, result.Amount)
 		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: stateMessage(result)})
+	}
+}
+
+// stream the state of the auction as it changes
+func handleStream(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item := r.URL.Query().Get("item")
+		version := func(ctx context.Context) (int, error) {
+			response, err := tc.QueryWorkflow(ctx, item, "", temporal.QueryTypeVersion)
+			if err != nil {
+				return 0, err
+			}
+			var v int
+			err = response.Get(&v)
+			return v, err
+		}
+		wait := func(ctx context.Context, seen int) (int, error) {
+			var v int
+			err := updateAuction(ctx, tc, item, temporal.UpdateTypeWaitVersion, &v, seen)
+			return v, err
+		}
+		send := func(ctx context.Context, sse *convenience.SSEWriter) (bool, error) {
+			response, err := tc.QueryWorkflow(ctx, item, "", temporal.QueryTypeState)
+			if err != nil {
+				return false, err
+			}
+			var result temporal.QueryResultState
+			if err = response.Get(&result); err != nil {
+				return false, err
+			}
+			event := convenience.StreamEvent{Message: stateMessage(result), State: result}
+			return result.Closed, sse.WriteEvent("state", event)
+		}
+		err := convenience.StreamOnChange(w, r, version, wait, send)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+		}
+	}
+}
+
+// stateMessage describes the state of the auction
+func stateMessage(result temporal.QueryResultState) string {
+	var msg string
+	switch {
+	case result.Status == temporal.AuctionStatusScheduled:
+		msg = fmt.Sprintf(
+			"auction opens at %s and ends at %s",
+			result.StartTime.Format(time.RFC3339), result.EndTime.Format(time.RFC3339))
+	case result.Type == temporal.AuctionTypeDutch && result.Bidder != "":
+		msg = fmt.Sprintf("sold to %s for %s", result.Bidder, result.Amount)
+	case result.Type == temporal.AuctionTypeDutch && !result.NextDropTime.IsZero():
+		msg = fmt.Sprintf(
+			"current price %s, next drop at %s; auction ends at %s",
+			result.Price, result.NextDropTime.Format(time.RFC3339), result.EndTime.Format(time.RFC3339))
+	case result.Type == temporal.AuctionTypeDutch:
+		msg = fmt.Sprintf(
+			"current price %s; auction ends at %s",
+			result.Price, result.EndTime.Format(time.RFC3339))
+	case result.Type == temporal.AuctionTypeUniformPrice && result.Closed:
+		msg = fmt.Sprintf(
+			"%d units closed with %d bids at a clearing price of %s",
+			result.Quantity, result.BidCount, result.Price)
+	case temporal.IsSealed(result.Type) && result.Bidder != "":
+		msg = fmt.Sprintf("won by %s bidding %s, paying %s", result.Bidder, result.Amount, result.Price)
+	case temporal.IsSealed(result.Type):
+		msg = fmt.Sprintf(
+			"%d sealed bids; auction ends at %s",
+			result.BidCount, result.EndTime.Format(time.RFC3339))
+	default:
+		msg = fmt.Sprintf(
+			"top bid by %s for %s; auction ends at %s",
+			result.Bidder, result.Amount, result.EndTime.Format(time.RFC3339))
+	}
+	if result.BuyNowPrice.IsPositive() {
+		msg += fmt.Sprintf("; buy it now for %s", result.BuyNowPrice)
 	}
+	return msg
 }
 
-// send a signal to the workflow with the supplied bid
+// query the workflow for a page of the bid log
+func handleGetBids(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		offset, limit := 0, 0
+		var err error
+		if v := r.URL.Query().Get("offset"); v != "" {
+			if offset, err = strconv.Atoi(v); err != nil {
+				convenience.WriteBadRequestError(w, fmt.Errorf("bad offset: %w", err))
+				return
+			}
+		}
+		if v := r.URL.Query().Get("limit"); v != "" {
+			if limit, err = strconv.Atoi(v); err != nil {
+				convenience.WriteBadRequestError(w, fmt.Errorf("bad limit: %w", err))
+				return
+			}
+		}
+		response, err := tc.QueryWorkflow(
+			r.Context(), r.URL.Query().Get("item"), "", temporal.QueryTypeBids, offset, limit)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var result temporal.AuctionBidsPage
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// query the workflow for the best bid of each bidder
+func handleGetLeaderboard(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := tc.QueryWorkflow(
+			r.Context(), r.URL.Query().Get("item"), "", temporal.QueryTypeLeaderboard)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var result []temporal.AuctionStanding
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// query the workflow for the timeline of the top bid, as JSON or as CSV with
+// format=csv
+func handleGetTimeline(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		response, err := tc.QueryWorkflow(
+			r.Context(), r.URL.Query().Get("item"), "", temporal.QueryTypeTimeline)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var result temporal.AuctionTimeline
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		if r.URL.Query().Get("format") != "csv" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"time", "amount", "currency", "bidder", "bid_count"})
+		for _, p := range result.Points {
+			cw.Write([]string{
+				p.Time.Format(time.RFC3339),
+				strconv.FormatFloat(p.Value.Amount.Float(), 'f', -1, 64),
+				p.Value.Amount.Currency,
+				p.Value.Bidder,
+				strconv.Itoa(p.Value.BidCount),
+			})
+		}
+		cw.Flush()
+	}
+}
+
+// writeQueryError reports queries refused by the workflow (e.g., sealed bids)
+// as bad requests and anything else as an internal error
+func writeQueryError(l *slog.Logger, w http.ResponseWriter, err error) {
+	var queryFailed *serviceerror.QueryFailed
+	if errors.As(err, &queryFailed) {
+		convenience.WriteBadRequestError(w, errors.New(queryFailed.Message))
+		return
+	}
+	convenience.WriteInternalError(l, w, err)
+}
+
+// updateAuction sends an update to the auction workflow and waits for it to
+// complete. Errors are returned as is so writeUpdateError can tell rejections
+// apart from failures.
+func updateAuction(ctx context.Context, tc client.Client, item, name string, result interface{}, args ...interface{}) error {
+	handle, err := tc.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   item,
+		UpdateName:   name,
+		Args:         args,
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		return err
+	}
+	return handle.Get(ctx, result)
+}
+
+// submit the supplied bid to the workflow via update and report whether it
+// was accepted; rejected bids are returned to the caller as a 400
 func handleBid(l *slog.Logger, tc client.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -96,32 +339,169 @@ func handleBid(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			return
 		}
 
-		err = tc.SignalWorkflow(r.Context(), payload.Item, "", temporal.SignalTypeBid, payload)
+		var result temporal.BidResult
+		err = updateAuction(r.Context(), tc, payload.Item, temporal.UpdateTypeBid, &result, payload)
 		if err != nil {
-			convenience.WriteBadRequestError(w, err)
+			writeUpdateError(l, w, err)
 			return
 		}
 
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// cancel an auction; it will send a cancellation instead of its results
+func handleCancel(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item := r.URL.Query().Get("item")
+		reason := r.URL.Query().Get("reason")
+		err := updateAuction(r.Context(), tc, item, temporal.UpdateTypeCancel, nil, reason)
+		if err != nil {
+			writeUpdateError(l, w, err)
+			return
+		}
 		convenience.WriteOK(w)
 	}
 }
 
-// handle the winning bid webhook
-func handleResult(l *slog.Logger, tc client.Client) http.HandlerFunc {
+// push out the end time of an auction
+func handleExtend(l *slog.Logger, tc client.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
+		item := r.URL.Query().Get("item")
+		by, err := time.ParseDuration(r.URL.Query().Get("by"))
+		if err != nil {
+			convenience.WriteBadRequestError(w, fmt.Errorf("bad extension: %w", err))
+			return
+		}
+		var endTime time.Time
+		err = updateAuction(r.Context(), tc, item, temporal.UpdateTypeExtend, &endTime, by)
+		if err != nil {
+			writeUpdateError(l, w, err)
+			return
+		}
+		msg := fmt.Sprintf("auction ends at %s", endTime.Format(time.RFC3339))
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
+	}
+}
 
-		var payload temporal.AuctionBid
+// close an auction early; it settles as if the end time had been reached
+func handleClose(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		item := r.URL.Query().Get("item")
+		err := updateAuction(r.Context(), tc, item, temporal.UpdateTypeCloseNow, nil)
+		if err != nil {
+			writeUpdateError(l, w, err)
+			return
+		}
+		convenience.WriteOK(w)
+	}
+}
+
+// return the stored result of a completed auction
+func handleGetResult(l *slog.Logger, results *resultStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		result, ok := results.Get(r.URL.Query().Get("item"))
+		if !ok {
+			convenience.WriteEmptyResultError(w)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// writeUpdateError writes the error returned by an update. Rejections from
+// the workflow are the caller's fault and are returned as a 400, updates sent
+// to an auction that doesn't exist are a 404, and anything else (e.g., the
+// server being unreachable) is an internal error.
+func writeUpdateError(l *slog.Logger, w http.ResponseWriter, err error) {
+	var appErr *sdktemporal.ApplicationError
+	var notFound *serviceerror.NotFound
+	switch {
+	case errors.As(err, &appErr):
+		convenience.WriteBadRequestError(w, errors.New(appErr.Message()))
+	case errors.As(err, &notFound):
+		convenience.WriteNotFoundError(w, err)
+	default:
+		convenience.WriteInternalError(l, w, err)
+	}
+}
+
+// handle the auction result webhook
+func handleResult(l *slog.Logger, results *resultStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.AuctionResult
 		err := json.NewDecoder(r.Body).Decode(&payload)
 		if err != nil {
 			convenience.WriteBadRequestError(w, err)
 			return
 		}
 
+		settlement := ""
+		if payload.Settlement != nil {
+			settlement = payload.Settlement.Status
+		}
 		l.Info(
 			"got auction result",
 			"item", payload.Item,
+			"outcome", payload.Outcome,
+			"bidder", payload.WinningBid.Bidder,
+			"amount", payload.WinningBid.Amount,
+			"price", payload.Price,
+			"reserve_price", payload.ReservePrice,
+			"bid_count", payload.BidCount,
+			"closed_at", payload.ClosedAt,
+			"close_reason", payload.CloseReason,
+			"allocations", payload.Allocations,
+			"settlement", settlement,
+		)
+		results.Put(payload)
+		convenience.WriteOK(w)
+	}
+}
+
+// handle the auction cancellation webhook
+func handleCancelled(l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.AuctionCancellation
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+
+		l.Info(
+			"got auction cancellation",
+			"item", payload.Item,
+			"reason", payload.Reason,
+			"bid_count", payload.BidCount,
+			"cancelled_at", payload.CancelledAt,
+		)
+		convenience.WriteOK(w)
+	}
+}
+
+// handle an outbid notification
+func handleOutbid(l *slog.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+
+		var payload temporal.OutbidNotification
+		err := json.NewDecoder(r.Body).Decode(&payload)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+
+		l.Info(
+			"got outbid notification",
+			"item", payload.Item,
 			"bidder", payload.Bidder,
-			"amount", payload.Amount,
+			"top_amount", payload.TopAmount,
+			"outbid_at", payload.OutbidAt,
 		)
 		convenience.WriteOK(w)
 	}
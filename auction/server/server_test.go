@@ -0,0 +1,46 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brojonat/temporal-examples/convenience"
+	"go.temporal.io/api/serviceerror"
+	sdktemporal "go.temporal.io/sdk/temporal"
+)
+
+func TestWriteUpdateError(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rejection := sdktemporal.NewApplicationError("bid is too low", "")
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantError string
+	}{
+		{name: "rejected by the workflow", err: rejection, wantCode: http.StatusBadRequest, wantError: "bid is too low"},
+		{name: "wrapped rejection", err: fmt.Errorf("update failed: %w", rejection), wantCode: http.StatusBadRequest, wantError: "bid is too low"},
+		{name: "unknown auction", err: serviceerror.NewNotFound("workflow not found"), wantCode: http.StatusNotFound, wantError: "workflow not found"},
+		{name: "server unavailable", err: serviceerror.NewUnavailable("connection refused"), wantCode: http.StatusInternalServerError, wantError: "internal error"},
+		{name: "request cancelled", err: context.Canceled, wantCode: http.StatusInternalServerError, wantError: "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeUpdateError(l, w, tt.err)
+			var resp convenience.DefaultJSONResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantCode || resp.Error != tt.wantError {
+				t.Errorf("got %d %q, want %d %q", w.Code, resp.Error, tt.wantCode, tt.wantError)
+			}
+		})
+	}
+}
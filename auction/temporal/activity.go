@@ -7,16 +7,49 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+const (
+	// error type returned when a webhook can't be sent as given
+	ErrTypeBadWebhook = "BadWebhook"
 )
 
-func RunAuctionCompleteWebhook(ctx context.Context, endpoint string, bid AuctionBid) error {
-	b, err := json.Marshal(bid)
+func RunAuctionCompleteWebhook(ctx context.Context, endpoint string, result AuctionResult) error {
+	return postWebhook(ctx, endpoint, result)
+}
+
+func RunAuctionCancelledWebhook(ctx context.Context, endpoint string, cancellation AuctionCancellation) error {
+	return postWebhook(ctx, endpoint, cancellation)
+}
+
+func RunAuctionOutbidWebhook(ctx context.Context, endpoint string, n OutbidNotification) error {
+	return postWebhook(ctx, endpoint, n)
+}
+
+func RunCatalogReportWebhook(ctx context.Context, endpoint string, report CatalogReport) error {
+	return postWebhook(ctx, endpoint, report)
+}
+
+// postWebhook posts the payload as JSON to the endpoint. A payload that can't
+// be encoded or an endpoint that isn't an http(s) URL can never be sent, so
+// those fail without retrying; any response but a 200 is retried.
+func postWebhook(ctx context.Context, endpoint string, payload interface{}) error {
+	b, err := json.Marshal(payload)
 	if err != nil {
-		return nil
+		msg := fmt.Sprintf("could not encode webhook payload: %s", err)
+		return temporal.NewNonRetryableApplicationError(msg, ErrTypeBadWebhook, err)
+	}
+	if u, err := url.Parse(endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		msg := fmt.Sprintf("bad webhook endpoint %q", endpoint)
+		return temporal.NewNonRetryableApplicationError(msg, ErrTypeBadWebhook, err)
 	}
-	r, err := http.NewRequest(http.MethodPost, endpoint, bytes.NewReader(b))
+	r, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(b))
 	if err != nil {
-		return nil
+		msg := fmt.Sprintf("could not build webhook request: %s", err)
+		return temporal.NewNonRetryableApplicationError(msg, ErrTypeBadWebhook, err)
 	}
 	res, err := http.DefaultClient.Do(r)
 	if err != nil {
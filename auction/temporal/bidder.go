@@ -0,0 +1,293 @@
+package temporal
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"time"
+
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// WorkflowBidder is a long running entity workflow that keeps a registered
+// bidder's credit limit and the amount they currently have committed across
+// open auctions. Auctions that require registration commit a bid against the
+// bidder's credit before accepting it, and release the commitment once the
+// bidder is outbid or the auction ends. Each auction numbers its commitments,
+// and a release only drops the commitment it names, so a release that arrives
+// late never drops a newer commitment. The workflow runs until the bidder is
+// deregistered (i.e., the workflow is terminated), continuing as new whenever
+// its history grows too large.
+
+const (
+	// query types
+	QueryTypeBidder = "bidder"
+
+	// update types
+	UpdateTypeCommit   = "commit"
+	UpdateTypeSetLimit = "set_limit"
+
+	// signal types
+	SignalTypeRelease = "release"
+
+	// error type returned when a bidder's credit can't cover a bid
+	ErrTypeCreditRefused = "CreditRefused"
+)
+
+// BidderWorkflowID returns the ID of the bidder's registry workflow.
+func BidderWorkflowID(bidder string) string {
+	return fmt.Sprintf("bidder: %s", bidder)
+}
+
+type RunBidderWFRequest struct {
+	Bidder      string       `json:"bidder"`
+	CreditLimit Money        `json:"credit_limit"`
+	State       *BidderState `json:"state,omitempty"`
+}
+
+// Validate checks that the request registers a bidder with a usable limit.
+func (r RunBidderWFRequest) Validate() error {
+	if r.Bidder == "" {
+		return fmt.Errorf("must supply a bidder")
+	}
+	if r.CreditLimit.Units < 0 {
+		return fmt.Errorf("credit limit cannot be negative")
+	}
+	if c := r.CreditLimit.Currency; c != "" && (len(c) != 3 || c != strings.ToUpper(c)) {
+		return fmt.Errorf("bad currency code %q", c)
+	}
+	return nil
+}
+
+// BidderCommitment commits some of a bidder's credit to their bid on an item.
+// A later commitment for the same item replaces the earlier one; Seq numbers
+// the auction's commitments so releases can tell them apart.
+type BidderCommitment struct {
+	Bidder string `json:"bidder"`
+	Item   string `json:"item"`
+	Amount Money  `json:"amount"`
+	Seq    int    `json:"seq"`
+}
+
+// BidderRelease releases the commitment to an item, unless it's been replaced
+// by a later commitment than the one numbered Seq.
+type BidderRelease struct {
+	Item string `json:"item"`
+	Seq  int    `json:"seq"`
+}
+
+// BidderState is the bidder's credit limit and their commitments by item.
+// CommitmentSeqs holds the Seq of each commitment.
+type BidderState struct {
+	Bidder         string           `json:"bidder"`
+	CreditLimit    Money            `json:"credit_limit"`
+	Committed      Money            `json:"committed"`
+	Available      Money            `json:"available"`
+	Commitments    map[string]Money `json:"commitments"`
+	CommitmentSeqs map[string]int   `json:"commitment_seqs"`
+}
+
+// release drops the commitment the release names, ignoring stale releases.
+func (s *BidderState) release(rel BidderRelease) {
+	if rel.Seq < s.CommitmentSeqs[rel.Item] {
+		return
+	}
+	delete(s.Commitments, rel.Item)
+	delete(s.CommitmentSeqs, rel.Item)
+}
+
+// committed returns the total committed across items.
+func (s BidderState) committed() Money {
+	total := Money{Currency: s.CreditLimit.Currency}
+	for _, item := range slices.Sorted(maps.Keys(s.Commitments)) {
+		total = total.Add(s.Commitments[item])
+	}
+	return total
+}
+
+func RunBidderWF(ctx workflow.Context, r RunBidderWFRequest) error {
+	if err := r.Validate(); err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidRequest", err)
+	}
+	s := BidderState{Bidder: r.Bidder, Commitments: map[string]Money{}}
+	if r.State != nil {
+		s = *r.State
+	} else {
+		s.CreditLimit, _ = r.CreditLimit.In(cmp.Or(r.CreditLimit.Currency, DefaultCurrency))
+	}
+	if s.CommitmentSeqs == nil {
+		s.CommitmentSeqs = map[string]int{}
+	}
+
+	// register a handler to return the bidder's credit
+	err := workflow.SetQueryHandler(ctx, QueryTypeBidder, func() (BidderState, error) {
+		st := s
+		st.Committed = s.committed()
+		st.Available = s.CreditLimit.Sub(st.Committed)
+		return st, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// commit credit to a bid; commitments that would take the bidder over
+	// their limit are rejected
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeCommit,
+		func(ctx workflow.Context, c BidderCommitment) error {
+			s.Commitments[c.Item] = c.Amount
+			s.CommitmentSeqs[c.Item] = c.Seq
+			return nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, c BidderCommitment) error {
+				if c.Amount.Currency != s.CreditLimit.Currency {
+					return fmt.Errorf("credit for %s is in %s, not %s", s.Bidder, s.CreditLimit.Currency, c.Amount.Currency)
+				}
+				total := s.committed().Sub(s.Commitments[c.Item]).Add(c.Amount)
+				if total.Cmp(s.CreditLimit) > 0 {
+					return fmt.Errorf(
+						"bid of %s would take %s over their credit limit of %s (%s available)",
+						c.Amount, s.Bidder, s.CreditLimit, s.CreditLimit.Sub(s.committed()).Add(s.Commitments[c.Item]))
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// adjust the credit limit; it can't be lowered below what's committed
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeSetLimit,
+		func(ctx workflow.Context, limit Money) error {
+			s.CreditLimit, _ = limit.In(s.CreditLimit.Currency)
+			return nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, limit Money) error {
+				limit, err := limit.In(s.CreditLimit.Currency)
+				if err != nil {
+					return err
+				}
+				if limit.Cmp(s.committed()) < 0 {
+					return fmt.Errorf("credit limit cannot be lowered below the %s committed", s.committed())
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// release commitments; uses a separate goroutine so that the main
+	// coroutine can wait for continue-as-new
+	releaseChan := workflow.GetSignalChannel(ctx, SignalTypeRelease)
+	workflow.Go(ctx, func(ictx workflow.Context) {
+		var rel BidderRelease
+		for {
+			releaseChan.Receive(ictx, &rel)
+			s.release(rel)
+		}
+	})
+
+	// the bidder lives on indefinitely, continuing as new to keep the history
+	// bounded
+	err = workflow.Await(ctx, func() bool {
+		info := workflow.GetInfo(ctx)
+		return info.GetContinueAsNewSuggested() || info.GetCurrentHistoryLength() > maxHistoryLength
+	})
+	if err != nil {
+		return err
+	}
+	err = workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) })
+	if err != nil {
+		return err
+	}
+	var rel BidderRelease
+	for releaseChan.ReceiveAsync(&rel) {
+		s.release(rel)
+	}
+	r.State = &s
+	return workflow.NewContinueAsNewError(ctx, RunBidderWF, r)
+}
+
+// bidCommitment returns the commitment covering the most a bid could cost the
+// bidder.
+func bidCommitment(r RunAuctionWFRequest, bid AuctionBid, seq int) (BidderCommitment, error) {
+	bid, err := bidIn(r.Currency, bid)
+	if err != nil {
+		return BidderCommitment{}, err
+	}
+	return BidderCommitment{
+		Bidder: bid.Bidder,
+		Item:   r.Item,
+		Amount: bidCeiling(bid).Mul(max(bid.Quantity, 1)),
+		Seq:    seq,
+	}, nil
+}
+
+// commitBid commits the bidder's credit. It fails if the bidder isn't
+// registered or can't cover the commitment.
+func commitBid(ctx workflow.Context, c BidderCommitment) error {
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 10 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        time.Second,
+			BackoffCoefficient:     2.0,
+			MaximumAttempts:        3,
+			NonRetryableErrorTypes: []string{ErrTypeCreditRefused},
+		},
+	})
+	var a *Activities
+	err := workflow.ExecuteActivity(ctx, a.CommitBid, c).Get(ctx, nil)
+	var appErr *temporal.ApplicationError
+	if errors.As(err, &appErr) && appErr.Type() == ErrTypeCreditRefused {
+		return appErr
+	}
+	return err
+}
+
+// releaseBid releases the bidder's commitment to an item.
+func releaseBid(ctx workflow.Context, c BidderCommitment) workflow.Future {
+	rel := BidderRelease{Item: c.Item, Seq: c.Seq}
+	return workflow.SignalExternalWorkflow(ctx, BidderWorkflowID(c.Bidder), "", SignalTypeRelease, rel)
+}
+
+// CommitBid asks the bidder's registry workflow to commit credit to a bid.
+// Refusals (including unregistered bidders) fail without retrying.
+func (a *Activities) CommitBid(ctx context.Context, c BidderCommitment) error {
+	handle, err := a.Client.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+		WorkflowID:   BidderWorkflowID(c.Bidder),
+		UpdateName:   UpdateTypeCommit,
+		Args:         []interface{}{c},
+		WaitForStage: client.WorkflowUpdateStageCompleted,
+	})
+	if err != nil {
+		var notFound *serviceerror.NotFound
+		if errors.As(err, &notFound) {
+			msg := fmt.Sprintf("bidder %s is not registered", c.Bidder)
+			return temporal.NewNonRetryableApplicationError(msg, ErrTypeCreditRefused, nil)
+		}
+		return err
+	}
+	if err = handle.Get(ctx, nil); err != nil {
+		var appErr *temporal.ApplicationError
+		if errors.As(err, &appErr) {
+			return temporal.NewNonRetryableApplicationError(appErr.Message(), ErrTypeCreditRefused, nil)
+		}
+		return err
+	}
+	return nil
+}
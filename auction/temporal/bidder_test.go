@@ -0,0 +1,85 @@
+package temporal
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type BidderWorkflowSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env *testsuite.TestWorkflowEnvironment
+}
+
+func TestBidderWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(BidderWorkflowSuite))
+}
+
+func (s *BidderWorkflowSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+}
+
+// at runs fn once the delay has passed (in workflow time).
+func (s *BidderWorkflowSuite) at(delay time.Duration, fn func()) {
+	s.env.RegisterDelayedCallback(fn, delay)
+}
+
+// commitAt commits credit through an update once the delay has passed.
+func (s *BidderWorkflowSuite) commitAt(delay time.Duration, c BidderCommitment) *updateOutcome {
+	outcome := &updateOutcome{}
+	s.at(delay, func() {
+		s.env.UpdateWorkflow(UpdateTypeCommit, c.Item+"-"+delay.String(), outcome, c)
+	})
+	return outcome
+}
+
+// committed queries the bidder's committed credit.
+func (s *BidderWorkflowSuite) committed() Money {
+	v, err := s.env.QueryWorkflow(QueryTypeBidder)
+	s.Require().NoError(err)
+	var state BidderState
+	s.Require().NoError(v.Get(&state))
+	return state.Committed
+}
+
+func (s *BidderWorkflowSuite) Test_StaleReleaseKeepsNewerCommitment() {
+	var afterStale, afterRelease Money
+	s.commitAt(time.Minute, BidderCommitment{Bidder: "alice", Item: "vase", Amount: usd(60), Seq: 1})
+	raise := s.commitAt(2*time.Minute, BidderCommitment{Bidder: "alice", Item: "vase", Amount: usd(80), Seq: 2})
+	s.at(3*time.Minute, func() {
+		s.env.SignalWorkflow(SignalTypeRelease, BidderRelease{Item: "vase", Seq: 1})
+	})
+	s.at(4*time.Minute, func() { afterStale = s.committed() })
+	s.at(5*time.Minute, func() {
+		s.env.SignalWorkflow(SignalTypeRelease, BidderRelease{Item: "vase", Seq: 2})
+	})
+	s.at(6*time.Minute, func() {
+		afterRelease = s.committed()
+		s.env.CancelWorkflow()
+	})
+
+	s.env.ExecuteWorkflow(RunBidderWF, RunBidderWFRequest{Bidder: "alice", CreditLimit: usd(100)})
+
+	s.NoError(raise.err)
+	s.Equal(usd(80), afterStale)
+	s.Equal(usd(0), afterRelease)
+}
+
+func (s *BidderWorkflowSuite) Test_CommitOverLimitRejected() {
+	var committed Money
+	s.commitAt(time.Minute, BidderCommitment{Bidder: "alice", Item: "vase", Amount: usd(60), Seq: 1})
+	over := s.commitAt(2*time.Minute, BidderCommitment{Bidder: "alice", Item: "lamp", Amount: usd(50), Seq: 1})
+	s.at(3*time.Minute, func() {
+		committed = s.committed()
+		s.env.CancelWorkflow()
+	})
+
+	s.env.ExecuteWorkflow(RunBidderWF, RunBidderWFRequest{Bidder: "alice", CreditLimit: usd(100)})
+
+	s.Error(over.err)
+	s.Equal(usd(60), committed)
+}
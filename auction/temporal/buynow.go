@@ -0,0 +1,22 @@
+package temporal
+
+// English auctions may offer a buy-it-now price. A bid at or above it ends
+// the auction immediately and the bidder pays the buy-it-now price. As on most
+// marketplaces, the option disappears once bidding passes some fraction of
+// the reserve price; with no fraction set it disappears with the first bid.
+
+// buyNowAvailable reports whether the buy-it-now option is still offered.
+func buyNowAvailable(r RunAuctionWFRequest, top AuctionBid) bool {
+	if !r.BuyNowPrice.IsPositive() || (r.Type != "" && r.Type != AuctionTypeEnglish) {
+		return false
+	}
+	if top.Bidder == "" {
+		return true
+	}
+	return top.Amount.Cmp(r.ReservePrice.Scale(r.BuyNowDisableFraction)) < 0
+}
+
+// isBuyNowBid reports whether a bid exercises the buy-it-now option.
+func isBuyNowBid(r RunAuctionWFRequest, top AuctionBid, bid AuctionBid) bool {
+	return buyNowAvailable(r, top) && bid.Amount.Cmp(r.BuyNowPrice) >= 0
+}
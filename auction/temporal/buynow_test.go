@@ -0,0 +1,27 @@
+package temporal
+
+import "testing"
+
+func TestBuyNowAvailable(t *testing.T) {
+	r := RunAuctionWFRequest{Item: "vase", ReservePrice: usd(100), BuyNowPrice: usd(200), BuyNowDisableFraction: 0.5}
+	tests := []struct {
+		name string
+		r    RunAuctionWFRequest
+		top  AuctionBid
+		want bool
+	}{
+		{name: "no bids yet", r: r, want: true},
+		{name: "bidding below the fraction", r: r, top: AuctionBid{Bidder: "alice", Amount: usd(40)}, want: true},
+		{name: "bidding reached the fraction", r: r, top: AuctionBid{Bidder: "alice", Amount: usd(50)}},
+		{name: "no fraction withdraws it with the first bid", r: RunAuctionWFRequest{ReservePrice: usd(100), BuyNowPrice: usd(200)}, top: AuctionBid{Bidder: "alice", Amount: usd(1)}},
+		{name: "no buy-it-now price", r: RunAuctionWFRequest{ReservePrice: usd(100)}},
+		{name: "not an english auction", r: RunAuctionWFRequest{Type: AuctionTypeVickrey, BuyNowPrice: usd(200)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := buyNowAvailable(tt.r, tt.top); got != tt.want {
+				t.Errorf("buyNowAvailable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
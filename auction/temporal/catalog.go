@@ -0,0 +1,188 @@
+package temporal
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// WorkflowCatalog runs a whole catalog of lots (e.g., an estate sale) as one
+// unit. Every lot is auctioned by its own RunAuctionWF child workflow. Lots
+// are auctioned under the item "<catalog>/<item>", which is also the child's
+// workflow ID, so they can be bid on and managed like any other auction
+// without clashing with auctions outside the catalog. Lots may close on a
+// staggered schedule. Instead of each lot sending its own results, the catalog
+// collects them and sends a single report to the catalog webhook once every
+// lot has closed. A lot that fails to run is reported with its error rather
+// than counted as unsold.
+
+const (
+	// query types
+	QueryTypeCatalog = "catalog"
+)
+
+type CatalogLot struct {
+	Item         string `json:"item"`
+	ReservePrice Money  `json:"reserve_price"`
+
+	// Duration overrides the catalog's duration for this lot
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+type RunCatalogWFRequest struct {
+	Catalog       string        `json:"catalog"`
+	Currency      string        `json:"currency"`
+	StartTime     time.Time     `json:"start_time"`
+	Duration      time.Duration `json:"duration"`
+	Stagger       time.Duration `json:"stagger"`
+	Lots          []CatalogLot  `json:"lots"`
+	Webhook       string        `json:"webhook"`
+	CancelWebhook string        `json:"cancel_webhook"`
+	NotifyWebhook string        `json:"notify_webhook"`
+
+	// RequireRegistration is passed on to every lot
+	RequireRegistration bool `json:"require_registration"`
+}
+
+// Validate checks that the catalog and every one of its lots can be run.
+func (r RunCatalogWFRequest) Validate() error {
+	if r.Catalog == "" {
+		return fmt.Errorf("must supply a catalog name")
+	}
+	if len(r.Lots) == 0 {
+		return fmt.Errorf("must supply at least one lot")
+	}
+	if r.Stagger < 0 {
+		return fmt.Errorf("stagger cannot be negative")
+	}
+	seen := map[string]bool{}
+	for i, lr := range r.lotRequests() {
+		if r.Lots[i].Item == "" {
+			return fmt.Errorf("lot %d must supply an item", i+1)
+		}
+		if seen[lr.Item] {
+			return fmt.Errorf("duplicate lot %q", lr.Item)
+		}
+		seen[lr.Item] = true
+		if err := lr.Validate(); err != nil {
+			return fmt.Errorf("lot %q: %w", lr.Item, err)
+		}
+	}
+	return nil
+}
+
+// lotItem returns the item a lot is auctioned under.
+func lotItem(catalog, item string) string {
+	return fmt.Sprintf("%s/%s", catalog, item)
+}
+
+// lotRequests returns the auction request for each lot. Lots are staggered so
+// that each closes Stagger after the one before it. The lots are run without
+// a webhook; their results are reported by the catalog instead.
+func (r RunCatalogWFRequest) lotRequests() []RunAuctionWFRequest {
+	reqs := make([]RunAuctionWFRequest, len(r.Lots))
+	for i, lot := range r.Lots {
+		dur := lot.Duration
+		if dur == 0 {
+			dur = r.Duration
+		}
+		reqs[i] = RunAuctionWFRequest{
+			StartTime:     r.StartTime,
+			Duration:      dur + time.Duration(i)*r.Stagger,
+			Item:          lotItem(r.Catalog, lot.Item),
+			Catalog:       r.Catalog,
+			Currency:      r.Currency,
+			ReservePrice:  lot.ReservePrice,
+			CancelWebhook: r.CancelWebhook,
+			NotifyWebhook: r.NotifyWebhook,
+
+			RequireRegistration: r.RequireRegistration,
+		}
+	}
+	return reqs
+}
+
+type CatalogReport struct {
+	Catalog string          `json:"catalog"`
+	Lots    int             `json:"lots"`
+	Open    int             `json:"open"`
+	Sold    int             `json:"sold"`
+	Unsold  int             `json:"unsold"`
+	Failed  int             `json:"failed"`
+	Gross   Money           `json:"gross"`
+	Results []AuctionResult `json:"results"`
+
+	// Errors holds the reason each failed lot couldn't be run, by item
+	Errors   map[string]string `json:"errors,omitempty"`
+	ClosedAt time.Time         `json:"closed_at"`
+}
+
+func RunCatalogWF(ctx workflow.Context, r RunCatalogWFRequest) error {
+	if err := r.Validate(); err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidRequest", err)
+	}
+
+	// register a handler to return the running totals of the catalog
+	report := CatalogReport{
+		Catalog: r.Catalog,
+		Lots:    len(r.Lots),
+		Open:    len(r.Lots),
+		Gross:   Money{Currency: cmp.Or(strings.ToUpper(r.Currency), DefaultCurrency)},
+		Results: []AuctionResult{},
+		Errors:  map[string]string{},
+	}
+	err := workflow.SetQueryHandler(ctx, QueryTypeCatalog, func() (CatalogReport, error) {
+		return report, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// start an auction for every lot and tally each one up as it closes; a
+	// lot that fails to run (e.g., its item is already being auctioned) is
+	// counted as failed
+	selector := workflow.NewSelector(ctx)
+	for _, lr := range r.lotRequests() {
+		cctx := workflow.WithChildOptions(ctx, workflow.ChildWorkflowOptions{WorkflowID: lr.Item})
+		selector.AddFuture(workflow.ExecuteChildWorkflow(cctx, RunAuctionWF, lr), func(f workflow.Future) {
+			report.Open--
+			var result AuctionResult
+			if err := f.Get(ctx, &result); err != nil {
+				workflow.GetLogger(ctx).Warn("lot failed", "catalog", r.Catalog, "item", lr.Item, "error", err)
+				report.Failed++
+				report.Errors[lr.Item] = err.Error()
+				return
+			}
+			report.Results = append(report.Results, result)
+			if result.Outcome != AuctionOutcomeSold {
+				report.Unsold++
+				return
+			}
+			report.Sold++
+			report.Gross = report.Gross.Add(result.Price)
+		})
+	}
+	for range r.Lots {
+		selector.Select(ctx)
+	}
+	report.ClosedAt = workflow.Now(ctx)
+
+	// send the webhook with the catalog report
+	rp := temporal.RetryPolicy{
+		InitialInterval:    time.Second,
+		BackoffCoefficient: 5.0,
+		MaximumInterval:    time.Second * 100,
+		MaximumAttempts:    0, // Unlimited
+	}
+	aopts := workflow.ActivityOptions{
+		StartToCloseTimeout: 60 * time.Minute,
+		RetryPolicy:         &rp,
+		HeartbeatTimeout:    60 * time.Second,
+	}
+	ctx = workflow.WithActivityOptions(ctx, aopts)
+	return workflow.ExecuteActivity(ctx, RunCatalogReportWebhook, r.Webhook, report).Get(ctx, nil)
+}
@@ -0,0 +1,133 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"go.temporal.io/sdk/workflow"
+)
+
+// runCatalog runs the catalog to completion and returns the report it sent.
+func (s *AuctionWorkflowSuite) runCatalog(r RunCatalogWFRequest) CatalogReport {
+	var report CatalogReport
+	s.env.OnActivity(RunCatalogReportWebhook, mock.Anything, "http://localhost:8080/webhook/catalog", mock.Anything).Return(
+		func(ctx context.Context, endpoint string, cr CatalogReport) error {
+			report = cr
+			return nil
+		}).Once()
+	s.env.ExecuteWorkflow(RunCatalogWF, r)
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	return report
+}
+
+func (s *AuctionWorkflowSuite) Test_CatalogReport() {
+	s.env.RegisterWorkflow(RunAuctionWF)
+	s.env.RegisterDelayedCallback(func() {
+		s.NoError(s.env.SignalWorkflowByID("estate/vase", SignalTypeBid, AuctionBid{Bidder: "alice", Amount: usd(30)}))
+		s.NoError(s.env.SignalWorkflowByID("estate/chair", SignalTypeBid, AuctionBid{Bidder: "bob", Amount: usd(40)}))
+		s.NoError(s.env.SignalWorkflowByID("estate/lamp", SignalTypeBid, AuctionBid{Bidder: "bob", Amount: usd(15)}))
+	}, time.Minute)
+	var midway CatalogReport
+	s.queryAt(65*time.Minute, &midway, QueryTypeCatalog)
+
+	report := s.runCatalog(RunCatalogWFRequest{
+		Catalog:  "estate",
+		Duration: time.Hour,
+		Stagger:  10 * time.Minute,
+		Lots: []CatalogLot{
+			{Item: "vase", ReservePrice: usd(25)},
+			{Item: "chair", ReservePrice: usd(50)},
+			{Item: "lamp", ReservePrice: usd(10)},
+		},
+		Webhook: "http://localhost:8080/webhook/catalog",
+	})
+
+	s.Equal(2, midway.Open)
+	s.Equal(1, midway.Sold)
+	s.Equal(3, report.Lots)
+	s.Zero(report.Open)
+	s.Equal(2, report.Sold)
+	s.Equal(1, report.Unsold)
+	s.Zero(report.Failed)
+	s.Equal(usd(45), report.Gross)
+	s.WithinDuration(s.start.Add(80*time.Minute), report.ClosedAt, 0)
+
+	// lots close in order, each Stagger after the one before
+	s.Require().Len(report.Results, 3)
+	for i, want := range []struct {
+		item    string
+		outcome string
+		closed  time.Duration
+	}{
+		{"estate/vase", AuctionOutcomeSold, time.Hour},
+		{"estate/chair", AuctionOutcomeReserveNotMet, 70 * time.Minute},
+		{"estate/lamp", AuctionOutcomeSold, 80 * time.Minute},
+	} {
+		s.Equal(want.item, report.Results[i].Item)
+		s.Equal(want.outcome, report.Results[i].Outcome)
+		s.WithinDuration(s.start.Add(want.closed), report.Results[i].ClosedAt, 0)
+	}
+	s.Empty(s.result.Item, "lots must not send their own results")
+}
+
+func (s *AuctionWorkflowSuite) Test_CatalogLotFailure() {
+	s.env.OnWorkflow(RunAuctionWF, mock.Anything, mock.Anything).Return(
+		func(ctx workflow.Context, r RunAuctionWFRequest) (AuctionResult, error) {
+			if r.Item == "estate/lamp" {
+				return AuctionResult{}, errors.New("workflow execution already started")
+			}
+			return AuctionResult{Item: r.Item, Outcome: AuctionOutcomeSold, Price: usd(30)}, nil
+		})
+
+	report := s.runCatalog(RunCatalogWFRequest{
+		Catalog:  "estate",
+		Duration: time.Hour,
+		Lots:     []CatalogLot{{Item: "vase"}, {Item: "lamp"}},
+		Webhook:  "http://localhost:8080/webhook/catalog",
+	})
+
+	s.Equal(1, report.Sold)
+	s.Zero(report.Unsold)
+	s.Equal(1, report.Failed)
+	s.Contains(report.Errors["estate/lamp"], "already started")
+	s.Equal(usd(30), report.Gross)
+}
+
+func TestCatalogLotRequests(t *testing.T) {
+	r := RunCatalogWFRequest{
+		Catalog:  "estate",
+		Duration: time.Hour,
+		Stagger:  time.Minute,
+		Lots:     []CatalogLot{{Item: "vase"}, {Item: "chair", Duration: 2 * time.Hour}},
+	}
+	reqs := r.lotRequests()
+	if len(reqs) != 2 {
+		t.Fatalf("lotRequests() returned %d requests, want 2", len(reqs))
+	}
+	for i, want := range []struct {
+		item     string
+		duration time.Duration
+	}{
+		{"estate/vase", time.Hour},
+		{"estate/chair", 2*time.Hour + time.Minute},
+	} {
+		if reqs[i].Item != want.item || reqs[i].Duration != want.duration {
+			t.Errorf("lot %d is %s for %s, want %s for %s", i, reqs[i].Item, reqs[i].Duration, want.item, want.duration)
+		}
+		if reqs[i].Catalog != "estate" || reqs[i].Webhook != "" {
+			t.Errorf("lot %d should report to the catalog, not a webhook", i)
+		}
+		if err := reqs[i].Validate(); err != nil {
+			t.Errorf("lot %d is invalid: %s", i, err)
+		}
+	}
+
+	r.Lots = append(r.Lots, CatalogLot{})
+	if err := r.Validate(); err == nil {
+		t.Errorf("Validate() accepted a lot without an item")
+	}
+}
@@ -0,0 +1,15 @@
+package temporal
+
+import "fmt"
+
+// validateDutchBid checks that a bid accepts the current asking price of a
+// dutch auction. Proxy maximums make no sense when the price only goes down.
+func validateDutchBid(price Money, bid AuctionBid) error {
+	if bid.MaxAmount.IsPositive() {
+		return fmt.Errorf("dutch auctions do not accept maximum bids")
+	}
+	if bid.Amount.Cmp(price) < 0 {
+		return fmt.Errorf("bid of %s does not meet the current price of %s", bid.Amount, price)
+	}
+	return nil
+}
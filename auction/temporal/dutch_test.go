@@ -0,0 +1,24 @@
+package temporal
+
+import "testing"
+
+func TestValidateDutchBid(t *testing.T) {
+	tests := []struct {
+		name    string
+		bid     AuctionBid
+		wantErr bool
+	}{
+		{name: "at the price", bid: AuctionBid{Bidder: "alice", Amount: usd(80)}},
+		{name: "above the price", bid: AuctionBid{Bidder: "alice", Amount: usd(90)}},
+		{name: "below the price", bid: AuctionBid{Bidder: "alice", Amount: usd(79.99)}, wantErr: true},
+		{name: "maximum bid", bid: AuctionBid{Bidder: "alice", Amount: usd(80), MaxAmount: usd(90)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateDutchBid(usd(80), tt.bid)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateDutchBid() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,171 @@
+package temporal
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// The auction keeps an ordered log of every accepted bid so disputes can be
+// resolved after the fact. Hidden maximums and callback URLs are kept in the
+// log (they need to survive continue-as-new) but are stripped before the log
+// is served.
+//
+// The log is carried over in the input of the next run when a busy auction
+// continues as new, so it has to stay well under the payload size limit. Only
+// the most recent maxCarriedBids bids are carried over, plus the first and
+// best (latest, in sealed auctions) bid of every bidder and the last one that
+// carried a callback URL, so the leaderboard and outbid notifications are
+// unaffected. Older bids drop out of the bids query; each page reports how
+// many have been dropped.
+
+const (
+	// query types
+	QueryTypeBids        = "bids"
+	QueryTypeLeaderboard = "leaderboard"
+
+	// default and maximum page size for the bids query
+	defaultBidsPageSize = 50
+	maxBidsPageSize     = 500
+
+	// continue as new once the history grows past this many events
+	maxHistoryLength = 10000
+	// number of recent bids carried over to the next run; a few hundred bytes
+	// each keeps the log to a fraction of the 2MB payload limit
+	maxCarriedBids = 1000
+)
+
+// AuctionBidRecord is an entry in the bid log.
+type AuctionBidRecord struct {
+	Seq  int        `json:"seq"`
+	Time time.Time  `json:"time"`
+	Bid  AuctionBid `json:"bid"`
+}
+
+// AuctionBidsPage is a page of the bid log returned by the bids query.
+type AuctionBidsPage struct {
+	Total   int                `json:"total"`
+	Dropped int                `json:"dropped,omitempty"`
+	Offset  int                `json:"offset"`
+	Bids    []AuctionBidRecord `json:"bids"`
+}
+
+// AuctionStanding is a bidder's best bid and rank on the leaderboard.
+type AuctionStanding struct {
+	Rank     int    `json:"rank"`
+	Bidder   string `json:"bidder"`
+	Amount   Money  `json:"amount"`
+	Quantity int    `json:"quantity,omitempty"`
+}
+
+// checkBidsVisible returns an error if the bid log may not be served yet.
+func checkBidsVisible(auctionType string, closed bool) error {
+	if IsSealed(auctionType) && !closed {
+		return fmt.Errorf("bids are sealed until the auction closes")
+	}
+	return nil
+}
+
+// pageBids returns a page of the bid log with private fields removed.
+func pageBids(log []AuctionBidRecord, offset, limit int) AuctionBidsPage {
+	if limit <= 0 {
+		limit = defaultBidsPageSize
+	}
+	limit = min(limit, maxBidsPageSize)
+	offset = min(max(offset, 0), len(log))
+	end := min(offset+limit, len(log))
+	page := AuctionBidsPage{Total: len(log), Offset: offset, Bids: []AuctionBidRecord{}}
+	if len(log) > 0 {
+		page.Dropped = log[len(log)-1].Seq - len(log)
+	}
+	for _, rec := range log[offset:end] {
+		rec.Bid.MaxAmount = Money{}
+		rec.Bid.CallbackURL = ""
+		page.Bids = append(page.Bids, rec)
+	}
+	return page
+}
+
+// trimBidLog returns the part of the log carried over to the next run: the
+// most recent maxCarriedBids bids, plus the first and standing bid of every
+// bidder so rankBidders gives the same result on the trimmed log, and their
+// last bid with a callback URL so callbackFor does too.
+func trimBidLog(auctionType string, log []AuctionBidRecord) []AuctionBidRecord {
+	if len(log) <= maxCarriedBids {
+		return log
+	}
+	keep := map[int]bool{}
+	first := map[string]bool{}
+	standing := map[string]AuctionBidRecord{}
+	callback := map[string]int{}
+	for _, rec := range log {
+		if rec.Bid.CallbackURL != "" {
+			callback[rec.Bid.Bidder] = rec.Seq
+		}
+		if !first[rec.Bid.Bidder] {
+			first[rec.Bid.Bidder] = true
+			keep[rec.Seq] = true
+		}
+		prev, ok := standing[rec.Bid.Bidder]
+		if !ok || IsSealed(auctionType) || bidCeiling(rec.Bid).Cmp(bidCeiling(prev.Bid)) > 0 {
+			standing[rec.Bid.Bidder] = rec
+		}
+	}
+	for _, rec := range standing {
+		keep[rec.Seq] = true
+	}
+	for _, seq := range callback {
+		keep[seq] = true
+	}
+
+	cut := len(log) - maxCarriedBids
+	trimmed := []AuctionBidRecord{}
+	for i, rec := range log {
+		if i >= cut || keep[rec.Seq] {
+			trimmed = append(trimmed, rec)
+		}
+	}
+	return trimmed
+}
+
+// rankBidders returns the best bid of each bidder, highest first. Ties are
+// ranked by whoever bid first. The current leader of an open auction is shown
+// at the visible price rather than their hidden maximum; everyone else has
+// already been outbid, so their best bid is whatever they were willing to pay.
+// In sealed auctions only each bidder's latest bid counts.
+func rankBidders(auctionType string, log []AuctionBidRecord, top AuctionBid) []AuctionStanding {
+	best := map[string]AuctionStanding{}
+	order := []string{}
+	for _, rec := range log {
+		amount := bidCeiling(rec.Bid)
+		prev, ok := best[rec.Bid.Bidder]
+		if !ok {
+			order = append(order, rec.Bid.Bidder)
+		}
+		if ok && !IsSealed(auctionType) && amount.Cmp(prev.Amount) <= 0 {
+			continue
+		}
+		best[rec.Bid.Bidder] = AuctionStanding{Bidder: rec.Bid.Bidder, Amount: amount, Quantity: rec.Bid.Quantity}
+	}
+	if st, ok := best[top.Bidder]; ok && !IsSealed(auctionType) {
+		st.Amount = top.Amount
+		best[top.Bidder] = st
+	}
+
+	standings := []AuctionStanding{}
+	for _, b := range order {
+		standings = append(standings, best[b])
+	}
+	slices.SortStableFunc(standings, func(a, b AuctionStanding) int {
+		return b.Amount.Cmp(a.Amount)
+	})
+	// the leader keeps first place even if an outbid bidder tied them
+	if i := slices.IndexFunc(standings, func(s AuctionStanding) bool { return s.Bidder == top.Bidder }); i > 0 {
+		leader := standings[i]
+		standings = slices.Insert(slices.Delete(standings, i, i+1), 0, leader)
+	}
+	for i := range standings {
+		standings[i].Rank = i + 1
+	}
+	return standings
+}
@@ -0,0 +1,136 @@
+package temporal
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPageBids(t *testing.T) {
+	log := []AuctionBidRecord{}
+	for i := 1; i <= 5; i++ {
+		log = append(log, AuctionBidRecord{Seq: i, Bid: AuctionBid{Bidder: "alice", Amount: usd(float64(10 * i)), MaxAmount: usd(100), CallbackURL: "http://alice.example.com"}})
+	}
+	tests := []struct {
+		name       string
+		log        []AuctionBidRecord
+		offset     int
+		limit      int
+		wantOffset int
+		wantSeqs   []int
+	}{
+		{name: "first page", log: log, limit: 2, wantSeqs: []int{1, 2}},
+		{name: "middle page", log: log, offset: 2, limit: 2, wantOffset: 2, wantSeqs: []int{3, 4}},
+		{name: "last page is short", log: log, offset: 4, limit: 2, wantOffset: 4, wantSeqs: []int{5}},
+		{name: "default page size", log: log, wantSeqs: []int{1, 2, 3, 4, 5}},
+		{name: "offset past the end", log: log, offset: 10, limit: 2, wantOffset: 5, wantSeqs: []int{}},
+		{name: "negative offset", log: log, offset: -1, limit: 1, wantSeqs: []int{1}},
+		{name: "empty log", wantSeqs: []int{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := pageBids(tt.log, tt.offset, tt.limit)
+			if page.Total != len(tt.log) || page.Offset != tt.wantOffset || page.Dropped != 0 {
+				t.Errorf("pageBids() total %d, offset %d, dropped %d, want %d, %d, 0", page.Total, page.Offset, page.Dropped, len(tt.log), tt.wantOffset)
+			}
+			seqs := []int{}
+			for _, rec := range page.Bids {
+				seqs = append(seqs, rec.Seq)
+				if !rec.Bid.MaxAmount.IsZero() || rec.Bid.CallbackURL != "" {
+					t.Errorf("pageBids() served the hidden maximum or callback URL of bid %d", rec.Seq)
+				}
+			}
+			if fmt.Sprint(seqs) != fmt.Sprint(tt.wantSeqs) {
+				t.Errorf("pageBids() = %v, want %v", seqs, tt.wantSeqs)
+			}
+		})
+	}
+}
+
+func TestRankBidders(t *testing.T) {
+	log := []AuctionBidRecord{
+		{Seq: 1, Bid: AuctionBid{Bidder: "alice", Amount: usd(30)}},
+		{Seq: 2, Bid: AuctionBid{Bidder: "bob", Amount: usd(40)}},
+		{Seq: 3, Bid: AuctionBid{Bidder: "carol", Amount: usd(35)}},
+		{Seq: 4, Bid: AuctionBid{Bidder: "alice", Amount: usd(45), MaxAmount: usd(60)}},
+		{Seq: 5, Bid: AuctionBid{Bidder: "dave", Amount: usd(40)}},
+	}
+	tests := []struct {
+		name        string
+		auctionType string
+		log         []AuctionBidRecord
+		top         AuctionBid
+		want        string
+	}{
+		{
+			name:        "leader shown at the visible price",
+			auctionType: AuctionTypeEnglish,
+			log:         log,
+			top:         AuctionBid{Bidder: "alice", Amount: usd(41)},
+			want:        "[{1 alice 41.00 USD 0} {2 bob 40.00 USD 0} {3 dave 40.00 USD 0} {4 carol 35.00 USD 0}]",
+		},
+		{
+			name:        "leader keeps first place in a tie",
+			auctionType: AuctionTypeEnglish,
+			log:         log[:2],
+			top:         AuctionBid{Bidder: "bob", Amount: usd(30)},
+			want:        "[{1 bob 30.00 USD 0} {2 alice 30.00 USD 0}]",
+		},
+		{
+			name:        "sealed bids count the latest bid",
+			auctionType: AuctionTypeVickrey,
+			log: []AuctionBidRecord{
+				{Seq: 1, Bid: AuctionBid{Bidder: "alice", Amount: usd(50)}},
+				{Seq: 2, Bid: AuctionBid{Bidder: "bob", Amount: usd(40)}},
+				{Seq: 3, Bid: AuctionBid{Bidder: "alice", Amount: usd(20)}},
+			},
+			want: "[{1 bob 40.00 USD 0} {2 alice 20.00 USD 0}]",
+		},
+		{
+			name: "no bids",
+			want: "[]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fmt.Sprint(rankBidders(tt.auctionType, tt.log, tt.top)); got != tt.want {
+				t.Errorf("rankBidders() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTrimBidLog(t *testing.T) {
+	// alice opens with a high maximum and bob and carol trade bids for ever
+	// after; the trimmed log keeps everyone's first bid, alice's best bid and
+	// carol's callback URL so the leaderboard and notifications don't change
+	log := []AuctionBidRecord{{Seq: 1, Bid: AuctionBid{Bidder: "alice", Amount: usd(10), MaxAmount: usd(500)}}}
+	for i := 2; i <= maxCarriedBids+100; i++ {
+		bidder := "bob"
+		if i%2 == 0 {
+			bidder = "carol"
+		}
+		log = append(log, AuctionBidRecord{Seq: i, Bid: AuctionBid{Bidder: bidder, Amount: usd(float64(i))}})
+	}
+	log[49].Bid.CallbackURL = "http://carol.example.com/outbid"
+	top := AuctionBid{Bidder: "carol", Amount: usd(float64(maxCarriedBids + 100))}
+
+	trimmed := trimBidLog(AuctionTypeEnglish, log)
+	if len(trimmed) != maxCarriedBids+4 {
+		t.Fatalf("trimBidLog() kept %d bids, want %d", len(trimmed), maxCarriedBids+4)
+	}
+	if trimmed[2].Seq != 3 || trimmed[3].Seq != 50 || trimmed[4].Seq != 101 || trimmed[len(trimmed)-1].Seq != maxCarriedBids+100 {
+		t.Errorf("trimBidLog() kept bids %d, %d, %d ... %d, want 3, 50, 101 ... %d", trimmed[2].Seq, trimmed[3].Seq, trimmed[4].Seq, trimmed[len(trimmed)-1].Seq, maxCarriedBids+100)
+	}
+	if got := callbackFor(trimmed, "carol"); got != "http://carol.example.com/outbid" {
+		t.Errorf("callbackFor() on the trimmed log = %q", got)
+	}
+	if got, want := fmt.Sprint(rankBidders(AuctionTypeEnglish, trimmed, top)), fmt.Sprint(rankBidders(AuctionTypeEnglish, log, top)); got != want {
+		t.Errorf("rankBidders() on the trimmed log = %s, want %s", got, want)
+	}
+	if page := pageBids(trimmed, 0, 1); page.Dropped != 96 {
+		t.Errorf("pageBids() dropped = %d, want 96", page.Dropped)
+	}
+	if short := log[:10]; len(trimBidLog(AuctionTypeEnglish, short)) != len(short) {
+		t.Errorf("trimBidLog() trimmed a short log")
+	}
+}
@@ -0,0 +1,234 @@
+package temporal
+
+import (
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Money is an exact amount of some currency, counted in the currency's minor
+// units (e.g., cents) so that prices never pick up floating point rounding
+// errors. Money without a currency is in DefaultCurrency; this is what older
+// clients that send plain float amounts (in major units) end up with.
+//
+// Money is sent over the wire as {"units": 1250, "currency": "USD"}, but may
+// also be decoded from a plain number (12.5) or a string ("12.50 USD").
+
+const DefaultCurrency = "USD"
+
+// currencyExponents lists the currencies whose minor unit isn't a hundredth.
+var currencyExponents = map[string]int{
+	"BHD": 3, "CLP": 0, "ISK": 0, "JOD": 3, "JPY": 0, "KRW": 0, "KWD": 3,
+	"OMR": 3, "TND": 3, "UGX": 0, "VND": 0,
+}
+
+// currencyExponent returns the number of decimal places in the currency.
+func currencyExponent(currency string) int {
+	if e, ok := currencyExponents[currency]; ok {
+		return e
+	}
+	return 2
+}
+
+type Money struct {
+	Units    int64  `json:"units"`
+	Currency string `json:"currency,omitempty"`
+}
+
+// NewMoney converts an amount in major units (e.g., dollars) to Money. Like
+// ParseMoney, it rejects amounts with more decimal places than the currency
+// has rather than rounding them.
+func NewMoney(amount float64, currency string) (Money, error) {
+	scale := math.Pow10(currencyExponent(cmp.Or(currency, DefaultCurrency)))
+	units := math.Round(amount * scale)
+	// allow for the error in representing amounts like 0.1 as a float
+	if math.Abs(amount*scale-units) > 1e-6 {
+		return Money{}, fmt.Errorf("amount %v has too many decimal places", amount)
+	}
+	return Money{Units: int64(units), Currency: currency}, nil
+}
+
+// ParseMoney parses an amount in major units with an optional currency code,
+// e.g., "12.50" or "12.50 EUR".
+func ParseMoney(s string) (Money, error) {
+	fields := strings.Fields(s)
+	if len(fields) < 1 || len(fields) > 2 {
+		return Money{}, fmt.Errorf("bad amount %q; expected e.g. \"12.50\" or \"12.50 EUR\"", s)
+	}
+	currency := ""
+	if len(fields) == 2 {
+		currency = strings.ToUpper(fields[1])
+		if len(currency) != 3 {
+			return Money{}, fmt.Errorf("bad currency code %q", fields[1])
+		}
+	}
+	exp := currencyExponent(cmp.Or(currency, DefaultCurrency))
+	whole, frac, _ := strings.Cut(fields[0], ".")
+	if len(frac) > exp {
+		return Money{}, fmt.Errorf("amount %q has too many decimal places", s)
+	}
+	units, err := strconv.ParseInt(whole+frac+strings.Repeat("0", exp-len(frac)), 10, 64)
+	if err != nil {
+		return Money{}, fmt.Errorf("bad amount %q: %w", s, err)
+	}
+	return Money{Units: units, Currency: currency}, nil
+}
+
+// In returns the amount in the given currency. Money without a currency is
+// taken to be in DefaultCurrency and rescaled to the currency's minor units,
+// unless that would drop decimal places (e.g., 12.5 in JPY); Money in any
+// other currency can't be converted.
+func (m Money) In(currency string) (Money, error) {
+	switch m.Currency {
+	case currency:
+		return m, nil
+	case "":
+		exp := currencyExponent(currency) - currencyExponent(DefaultCurrency)
+		if exp >= 0 {
+			return Money{Units: m.Units * int64(math.Pow10(exp)), Currency: currency}, nil
+		}
+		scale := int64(math.Pow10(-exp))
+		if m.Units%scale != 0 {
+			return m, fmt.Errorf("amount %s has too many decimal places for %s", m, currency)
+		}
+		return Money{Units: m.Units / scale, Currency: currency}, nil
+	}
+	return m, fmt.Errorf("amount in %s does not match the currency %s", m.Currency, currency)
+}
+
+func (m Money) IsZero() bool {
+	return m.Units == 0
+}
+
+func (m Money) IsPositive() bool {
+	return m.Units > 0
+}
+
+// Cmp compares two amounts of the same currency.
+func (m Money) Cmp(o Money) int {
+	return cmp.Compare(m.Units, o.Units)
+}
+
+func (m Money) Add(o Money) Money {
+	return Money{Units: m.Units + o.Units, Currency: cmp.Or(m.Currency, o.Currency)}
+}
+
+func (m Money) Sub(o Money) Money {
+	return Money{Units: m.Units - o.Units, Currency: cmp.Or(m.Currency, o.Currency)}
+}
+
+// Mul returns the amount multiplied by a whole number (e.g., a quantity).
+func (m Money) Mul(n int) Money {
+	return Money{Units: m.Units * int64(n), Currency: m.Currency}
+}
+
+// Scale returns the amount multiplied by f, rounded to the nearest minor unit.
+func (m Money) Scale(f float64) Money {
+	return Money{Units: int64(math.Round(float64(m.Units) * f)), Currency: m.Currency}
+}
+
+// String formats the amount in major units, e.g., "12.50 USD".
+func (m Money) String() string {
+	currency := cmp.Or(m.Currency, DefaultCurrency)
+	exp := currencyExponent(currency)
+	if exp == 0 {
+		return fmt.Sprintf("%d %s", m.Units, currency)
+	}
+	sign := ""
+	units := m.Units
+	if units < 0 {
+		sign, units = "-", -units
+	}
+	scale := int64(math.Pow10(exp))
+	return fmt.Sprintf("%s%d.%0*d %s", sign, units/scale, exp, units%scale, currency)
+}
+
+// Float returns the amount in major units. It's inexact, so it's only meant
+// for display (e.g., charts), never for arithmetic.
+func (m Money) Float() float64 {
+	return float64(m.Units) / math.Pow10(currencyExponent(cmp.Or(m.Currency, DefaultCurrency)))
+}
+
+func (m *Money) UnmarshalJSON(b []byte) error {
+	b = bytes.TrimSpace(b)
+	switch {
+	case bytes.Equal(b, []byte("null")):
+		return nil
+	case len(b) > 0 && b[0] == '"':
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		parsed, err := ParseMoney(s)
+		if err != nil {
+			return err
+		}
+		*m = parsed
+		return nil
+	case len(b) > 0 && b[0] == '{':
+		type money Money
+		return json.Unmarshal(b, (*money)(m))
+	}
+	var amount float64
+	if err := json.Unmarshal(b, &amount); err != nil {
+		return fmt.Errorf("could not decode money: %w", err)
+	}
+	parsed, err := NewMoney(amount, "")
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// maxMoney and minMoney return the larger and smaller of two amounts.
+func maxMoney(a, b Money) Money {
+	if b.Cmp(a) > 0 {
+		return b
+	}
+	return a
+}
+
+func minMoney(a, b Money) Money {
+	if b.Cmp(a) < 0 {
+		return b
+	}
+	return a
+}
+
+// withCurrency returns the request with its currency defaulted and every
+// amount converted to that currency.
+func (r RunAuctionWFRequest) withCurrency() (RunAuctionWFRequest, error) {
+	r.Currency = cmp.Or(strings.ToUpper(r.Currency), DefaultCurrency)
+	if len(r.Currency) != 3 {
+		return r, fmt.Errorf("bad currency code %q", r.Currency)
+	}
+	for _, m := range []*Money{&r.ReservePrice, &r.MinIncrement, &r.StartPrice, &r.PriceStep, &r.BuyNowPrice} {
+		converted, err := m.In(r.Currency)
+		if err != nil {
+			return r, err
+		}
+		*m = converted
+	}
+	return r, nil
+}
+
+// bidIn returns the bid with its amounts converted to the auction currency;
+// bids in any other currency are rejected.
+func bidIn(currency string, bid AuctionBid) (AuctionBid, error) {
+	for _, m := range []*Money{&bid.Amount, &bid.MaxAmount} {
+		converted, err := m.In(currency)
+		if err != nil && m.Currency != "" {
+			return bid, fmt.Errorf("bid in %s does not match the auction currency %s", m.Currency, currency)
+		}
+		if err != nil {
+			return bid, fmt.Errorf("bad bid: %w", err)
+		}
+		*m = converted
+	}
+	return bid, nil
+}
@@ -0,0 +1,144 @@
+package temporal
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: "12.50", want: Money{Units: 1250}},
+		{in: "12.5", want: Money{Units: 1250}},
+		{in: "12", want: Money{Units: 1200}},
+		{in: "0.01", want: Money{Units: 1}},
+		{in: "12.50 eur", want: Money{Units: 1250, Currency: "EUR"}},
+		{in: "1500 JPY", want: Money{Units: 1500, Currency: "JPY"}},
+		{in: "1.234 KWD", want: Money{Units: 1234, Currency: "KWD"}},
+		{in: "12.505", wantErr: true},
+		{in: "1.5 JPY", wantErr: true},
+		{in: "12.50 EURO", wantErr: true},
+		{in: "twelve", wantErr: true},
+		{in: "", wantErr: true},
+		{in: "1 2 3", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseMoney(%q) = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseMoney(%q) = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNewMoney(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{amount: 12.5, want: Money{Units: 1250}},
+		{amount: 0.1, want: Money{Units: 10}},
+		{amount: 19.99, currency: "EUR", want: Money{Units: 1999, Currency: "EUR"}},
+		{amount: 1500, currency: "JPY", want: Money{Units: 1500, Currency: "JPY"}},
+		{amount: 1.234, currency: "KWD", want: Money{Units: 1234, Currency: "KWD"}},
+		{amount: 12.505, wantErr: true},
+		{amount: 12.5, currency: "JPY", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NewMoney(tt.amount, tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("NewMoney(%v, %q) = %v, want an error", tt.amount, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("NewMoney(%v, %q) = %v, %v, want %v", tt.amount, tt.currency, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Money
+		wantErr bool
+	}{
+		{in: `{"units": 1250, "currency": "USD"}`, want: Money{Units: 1250, Currency: "USD"}},
+		{in: `12.5`, want: Money{Units: 1250}},
+		{in: `0.1`, want: Money{Units: 10}},
+		{in: `"12.50 EUR"`, want: Money{Units: 1250, Currency: "EUR"}},
+		{in: `null`, want: Money{}},
+		{in: `"12.505"`, wantErr: true},
+		{in: `12.505`, wantErr: true},
+		{in: `true`, wantErr: true},
+	}
+	for _, tt := range tests {
+		var got Money
+		err := json.Unmarshal([]byte(tt.in), &got)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("unmarshal %s = %v, want an error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("unmarshal %s = %v, %v, want %v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		in   Money
+		want string
+	}{
+		{in: Money{Units: 1250}, want: "12.50 USD"},
+		{in: Money{Units: -5, Currency: "EUR"}, want: "-0.05 EUR"},
+		{in: Money{Units: 1500, Currency: "JPY"}, want: "1500 JPY"},
+		{in: Money{Units: 1234, Currency: "KWD"}, want: "1.234 KWD"},
+	}
+	for _, tt := range tests {
+		if got := tt.in.String(); got != tt.want {
+			t.Errorf("%#v.String() = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyIn(t *testing.T) {
+	tests := []struct {
+		in       Money
+		currency string
+		want     Money
+		wantErr  bool
+	}{
+		{in: Money{Units: 1250}, currency: "USD", want: Money{Units: 1250, Currency: "USD"}},
+		{in: Money{Units: 1200}, currency: "JPY", want: Money{Units: 12, Currency: "JPY"}},
+		{in: Money{Units: 1250}, currency: "JPY", wantErr: true},
+		{in: Money{Units: 1250}, currency: "KWD", want: Money{Units: 12500, Currency: "KWD"}},
+		{in: Money{Units: 1250, Currency: "EUR"}, currency: "EUR", want: Money{Units: 1250, Currency: "EUR"}},
+		{in: Money{Units: 1250, Currency: "EUR"}, currency: "USD", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := tt.in.In(tt.currency)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v.In(%s) = %v, want an error", tt.in, tt.currency, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%v.In(%s) = %v, %v, want %v", tt.in, tt.currency, got, err, tt.want)
+		}
+	}
+}
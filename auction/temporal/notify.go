@@ -0,0 +1,95 @@
+package temporal
+
+import (
+	"maps"
+	"slices"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// When a bidder loses the lead of an english auction they are sent an "outbid"
+// notification, either to the callback URL they supplied with their bid or to
+// the auction's NotifyWebhook. Notifications are coalesced: each bidder gets
+// at most one notification per debounce window describing the latest top bid,
+// and nothing at all if they retake the lead before the window ends.
+// Notifications are best effort and never hold up the auction.
+
+const (
+	OutbidEventType = "outbid"
+
+	defaultNotifyDebounce = 30 * time.Second
+)
+
+// OutbidNotification is the payload sent to a bidder who lost the lead.
+type OutbidNotification struct {
+	Event     string    `json:"event"`
+	Item      string    `json:"item"`
+	Bidder    string    `json:"bidder"`
+	TopAmount Money     `json:"top_amount"`
+	OutbidAt  time.Time `json:"outbid_at"`
+}
+
+// callbackFor returns the callback URL most recently supplied by the bidder.
+func callbackFor(log []AuctionBidRecord, bidder string) string {
+	for i := len(log) - 1; i >= 0; i-- {
+		if log[i].Bid.Bidder == bidder && log[i].Bid.CallbackURL != "" {
+			return log[i].Bid.CallbackURL
+		}
+	}
+	return ""
+}
+
+// runOutbidNotifier flushes pending notifications once per debounce window
+// until the auction closes. Each notification is sent by its own activity
+// with a limited number of retries; failures are logged and otherwise ignored.
+// Anything still pending when the auction closes is dropped, since the
+// results supersede it.
+func runOutbidNotifier(ctx workflow.Context, r RunAuctionWFRequest, s *AuctionState, closed *bool) {
+	debounce := r.NotifyDebounce
+	if debounce <= 0 {
+		debounce = defaultNotifyDebounce
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		StartToCloseTimeout: 30 * time.Second,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:    time.Second,
+			BackoffCoefficient: 2.0,
+			MaximumAttempts:    3,
+		},
+	})
+	logger := workflow.GetLogger(ctx)
+
+	for {
+		workflow.Await(ctx, func() bool { return *closed || len(s.PendingOutbid) > 0 })
+		if *closed {
+			return
+		}
+		workflow.AwaitWithTimeout(ctx, debounce, func() bool { return *closed })
+		if *closed {
+			return
+		}
+
+		// map iteration order isn't deterministic, so send in bidder order
+		for _, bidder := range slices.Sorted(maps.Keys(s.PendingOutbid)) {
+			// the price may have moved on since the bidder was displaced
+			n := s.PendingOutbid[bidder]
+			n.TopAmount = s.TopBid.Amount
+			endpoint := callbackFor(s.Bids, bidder)
+			if endpoint == "" {
+				endpoint = r.NotifyWebhook
+			}
+			if endpoint == "" {
+				continue
+			}
+			workflow.Go(ctx, func(gctx workflow.Context) {
+				err := workflow.ExecuteActivity(gctx, RunAuctionOutbidWebhook, endpoint, n).Get(gctx, nil)
+				if err != nil {
+					logger.Warn("could not send outbid notification", "bidder", n.Bidder, "error", err)
+				}
+			})
+		}
+		s.PendingOutbid = map[string]OutbidNotification{}
+	}
+}
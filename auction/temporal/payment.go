@@ -0,0 +1,111 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"go.temporal.io/sdk/client"
+	"go.temporal.io/sdk/temporal"
+)
+
+// Payments are captured through a pluggable PaymentProcessor so the worker can
+// be wired up to a real payment provider. FakePaymentProcessor is a local
+// stand-in that approves everything except a configurable set of bidders.
+// Workers that don't configure a processor can still run auctions; their
+// payment captures fail without retrying and the auction settles as unpaid.
+
+const (
+	// error type returned when a capture is declined; declines are not retried
+	ErrTypePaymentDeclined = "PaymentDeclined"
+	// error type returned when the worker has no payment processor
+	ErrTypeNoPaymentProcessor = "NoPaymentProcessor"
+)
+
+// PaymentRequest asks a bidder to pay for an item.
+type PaymentRequest struct {
+	Item   string `json:"item"`
+	Bidder string `json:"bidder"`
+	Amount Money  `json:"amount"`
+}
+
+// PaymentReceipt records a successful capture.
+type PaymentReceipt struct {
+	ID         string    `json:"id"`
+	Item       string    `json:"item"`
+	Bidder     string    `json:"bidder"`
+	Amount     Money     `json:"amount"`
+	CapturedAt time.Time `json:"captured_at"`
+}
+
+// PaymentProcessor captures payment from a bidder.
+type PaymentProcessor interface {
+	Capture(ctx context.Context, req PaymentRequest) (PaymentReceipt, error)
+}
+
+// PaymentDeclinedError is returned by a PaymentProcessor when the bidder will
+// not (or cannot) pay.
+type PaymentDeclinedError struct {
+	Bidder string
+	Reason string
+}
+
+func (e *PaymentDeclinedError) Error() string {
+	return fmt.Sprintf("payment from %s declined: %s", e.Bidder, e.Reason)
+}
+
+// FakePaymentProcessor approves every capture except those from
+// DeclinedBidders.
+type FakePaymentProcessor struct {
+	DeclinedBidders []string
+
+	mu       sync.Mutex
+	captures int
+}
+
+func (p *FakePaymentProcessor) Capture(ctx context.Context, req PaymentRequest) (PaymentReceipt, error) {
+	if slices.Contains(p.DeclinedBidders, req.Bidder) {
+		return PaymentReceipt{}, &PaymentDeclinedError{Bidder: req.Bidder, Reason: "card declined"}
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.captures++
+	return PaymentReceipt{
+		ID:         fmt.Sprintf("fake-%d", p.captures),
+		Item:       req.Item,
+		Bidder:     req.Bidder,
+		Amount:     req.Amount,
+		CapturedAt: time.Now(),
+	}, nil
+}
+
+// Activities holds the auction activities that need external dependencies.
+type Activities struct {
+	Payments PaymentProcessor
+
+	// Client is used to reach the bidder registry workflows
+	Client client.Client
+}
+
+// CapturePayment captures payment for an item from the bidder. Declined
+// payments and workers without a payment processor fail without retrying;
+// anything else is retried until the payment deadline passes.
+func (a *Activities) CapturePayment(ctx context.Context, req PaymentRequest) (PaymentReceipt, error) {
+	if a.Payments == nil {
+		return PaymentReceipt{}, temporal.NewNonRetryableApplicationError(
+			"no payment processor configured", ErrTypeNoPaymentProcessor, nil)
+	}
+	receipt, err := a.Payments.Capture(ctx, req)
+	if err != nil {
+		var declined *PaymentDeclinedError
+		if errors.As(err, &declined) {
+			return PaymentReceipt{}, temporal.NewNonRetryableApplicationError(
+				declined.Error(), ErrTypePaymentDeclined, err)
+		}
+		return PaymentReceipt{}, err
+	}
+	return receipt, nil
+}
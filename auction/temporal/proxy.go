@@ -0,0 +1,76 @@
+package temporal
+
+import "fmt"
+
+// Proxy bidding lets a bidder submit a secret maximum. The workflow keeps the
+// leader's maximum hidden and only ever shows the visible price, which is
+// raised one increment at a time as far as needed to keep the leader on top.
+// Plain bids are simply proxy bids whose maximum equals their amount.
+
+// bidCeiling returns the most the bidder is willing to pay.
+func bidCeiling(b AuctionBid) Money {
+	return maxMoney(b.Amount, b.MaxAmount)
+}
+
+// validateProxyBid checks a bid against the current leader and visible price.
+// The leader may only raise their own maximum, while everyone else must be
+// willing to pay at least one increment over the visible price.
+func validateProxyBid(r RunAuctionWFRequest, top AuctionBid, leaderMax Money, bid AuctionBid) error {
+	ceiling := bidCeiling(bid)
+	if !ceiling.IsPositive() {
+		return fmt.Errorf("bid amount must be positive")
+	}
+	if bid.MaxAmount.IsPositive() && bid.Amount.Cmp(bid.MaxAmount) > 0 {
+		return fmt.Errorf("bid amount of %s exceeds the maximum of %s", bid.Amount, bid.MaxAmount)
+	}
+	if top.Bidder == "" {
+		return nil
+	}
+	if bid.Bidder == top.Bidder {
+		if ceiling.Cmp(leaderMax) <= 0 {
+			return fmt.Errorf("you are already the top bidder; a new bid must raise your maximum")
+		}
+		return nil
+	}
+	if ceiling.Cmp(top.Amount) <= 0 || ceiling.Cmp(top.Amount.Add(r.MinIncrement)) < 0 {
+		return fmt.Errorf("bid of %s does not beat the top bid of %s (minimum increment %s)",
+			ceiling, top.Amount, r.MinIncrement)
+	}
+	return nil
+}
+
+// resolveProxyBid applies a validated bid and returns the new visible top bid
+// along with the leader's hidden maximum. Ties go to the earlier bid.
+func resolveProxyBid(r RunAuctionWFRequest, top AuctionBid, leaderMax Money, bid AuctionBid) (AuctionBid, Money) {
+	ceiling := bidCeiling(bid)
+
+	// the leader is raising their own bid or maximum
+	if top.Bidder != "" && bid.Bidder == top.Bidder {
+		top.Amount = maxMoney(top.Amount, bid.Amount)
+		return top, ceiling
+	}
+
+	// the first bid opens at the reserve (or the bid amount, if higher) when
+	// the bidder's maximum allows it
+	if top.Bidder == "" {
+		opening := minMoney(ceiling, maxMoney(bid.Amount, maxMoney(r.ReservePrice, r.MinIncrement)))
+		if !opening.IsPositive() {
+			opening = ceiling
+		}
+		return AuctionBid{Item: r.Item, Bidder: bid.Bidder, Amount: opening}, ceiling
+	}
+
+	// the challenger outbids the leader's maximum and takes the lead, paying
+	// one increment over the displaced maximum (or the reserve, if higher)
+	if ceiling.Cmp(leaderMax) > 0 {
+		visible := minMoney(ceiling, leaderMax.Add(r.MinIncrement))
+		visible = maxMoney(visible, minMoney(ceiling, r.ReservePrice))
+		visible = maxMoney(visible, bid.Amount)
+		return AuctionBid{Item: r.Item, Bidder: bid.Bidder, Amount: visible}, ceiling
+	}
+
+	// the leader's maximum holds; raise their visible bid just enough to stay
+	// on top of the challenger
+	top.Amount = maxMoney(top.Amount, minMoney(leaderMax, ceiling.Add(r.MinIncrement)))
+	return top, leaderMax
+}
@@ -0,0 +1,134 @@
+package temporal
+
+import "testing"
+
+// usd returns an amount in dollars.
+func usd(amount float64) Money {
+	m, err := NewMoney(amount, "USD")
+	if err != nil {
+		panic(err)
+	}
+	return m
+}
+
+func TestResolveProxyBid(t *testing.T) {
+	r := RunAuctionWFRequest{Item: "vase", ReservePrice: usd(25), MinIncrement: usd(1)}
+	leader := AuctionBid{Item: "vase", Bidder: "alice", Amount: usd(25)}
+	tests := []struct {
+		name          string
+		top           AuctionBid
+		leaderMax     Money
+		bid           AuctionBid
+		wantBidder    string
+		wantAmount    Money
+		wantLeaderMax Money
+	}{
+		{
+			name:          "first bid opens at the reserve",
+			top:           AuctionBid{Item: "vase"},
+			bid:           AuctionBid{Bidder: "alice", Amount: usd(10), MaxAmount: usd(100)},
+			wantBidder:    "alice",
+			wantAmount:    usd(25),
+			wantLeaderMax: usd(100),
+		},
+		{
+			name:          "first bid above the reserve opens at its amount",
+			top:           AuctionBid{Item: "vase"},
+			bid:           AuctionBid{Bidder: "alice", Amount: usd(40), MaxAmount: usd(100)},
+			wantBidder:    "alice",
+			wantAmount:    usd(40),
+			wantLeaderMax: usd(100),
+		},
+		{
+			name:          "first bid below the reserve opens at its maximum",
+			top:           AuctionBid{Item: "vase"},
+			bid:           AuctionBid{Bidder: "alice", Amount: usd(10)},
+			wantBidder:    "alice",
+			wantAmount:    usd(10),
+			wantLeaderMax: usd(10),
+		},
+		{
+			name:          "leader's maximum holds against a lower challenger",
+			top:           leader,
+			leaderMax:     usd(100),
+			bid:           AuctionBid{Bidder: "bob", Amount: usd(50)},
+			wantBidder:    "alice",
+			wantAmount:    usd(51),
+			wantLeaderMax: usd(100),
+		},
+		{
+			name:          "leader's maximum wins a tie",
+			top:           leader,
+			leaderMax:     usd(100),
+			bid:           AuctionBid{Bidder: "bob", Amount: usd(100)},
+			wantBidder:    "alice",
+			wantAmount:    usd(100),
+			wantLeaderMax: usd(100),
+		},
+		{
+			name:          "challenger beats the leader's maximum by one increment",
+			top:           leader,
+			leaderMax:     usd(100),
+			bid:           AuctionBid{Bidder: "bob", Amount: usd(30), MaxAmount: usd(150)},
+			wantBidder:    "bob",
+			wantAmount:    usd(101),
+			wantLeaderMax: usd(150),
+		},
+		{
+			name:          "challenger pays at most their own maximum",
+			top:           leader,
+			leaderMax:     usd(100),
+			bid:           AuctionBid{Bidder: "bob", Amount: usd(100.50)},
+			wantBidder:    "bob",
+			wantAmount:    usd(100.50),
+			wantLeaderMax: usd(100.50),
+		},
+		{
+			name:          "leader raises their maximum without raising the price",
+			top:           leader,
+			leaderMax:     usd(100),
+			bid:           AuctionBid{Bidder: "alice", MaxAmount: usd(200)},
+			wantBidder:    "alice",
+			wantAmount:    usd(25),
+			wantLeaderMax: usd(200),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			top, leaderMax := resolveProxyBid(r, tt.top, tt.leaderMax, tt.bid)
+			if top.Bidder != tt.wantBidder || top.Amount != tt.wantAmount || leaderMax != tt.wantLeaderMax {
+				t.Errorf(
+					"got %s at %s (max %s), want %s at %s (max %s)",
+					top.Bidder, top.Amount, leaderMax, tt.wantBidder, tt.wantAmount, tt.wantLeaderMax)
+			}
+		})
+	}
+}
+
+func TestValidateProxyBid(t *testing.T) {
+	r := RunAuctionWFRequest{Item: "vase", MinIncrement: usd(5)}
+	top := AuctionBid{Item: "vase", Bidder: "alice", Amount: usd(50)}
+	tests := []struct {
+		name    string
+		top     AuctionBid
+		bid     AuctionBid
+		wantErr bool
+	}{
+		{name: "first bid", top: AuctionBid{}, bid: AuctionBid{Bidder: "alice", Amount: usd(1)}},
+		{name: "zero bid", top: AuctionBid{}, bid: AuctionBid{Bidder: "alice"}, wantErr: true},
+		{name: "amount above maximum", bid: AuctionBid{Bidder: "bob", Amount: usd(80), MaxAmount: usd(70)}, top: top, wantErr: true},
+		{name: "one increment over", top: top, bid: AuctionBid{Bidder: "bob", Amount: usd(55)}},
+		{name: "under one increment over", top: top, bid: AuctionBid{Bidder: "bob", Amount: usd(54.99)}, wantErr: true},
+		{name: "maximum covers the increment", top: top, bid: AuctionBid{Bidder: "bob", Amount: usd(51), MaxAmount: usd(60)}},
+		{name: "leader raises maximum", top: top, bid: AuctionBid{Bidder: "alice", MaxAmount: usd(90)}},
+		{name: "leader repeats maximum", top: top, bid: AuctionBid{Bidder: "alice", MaxAmount: usd(80)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateProxyBid(r, tt.top, usd(80), tt.bid)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("validateProxyBid() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package temporal
+
+import "fmt"
+
+// Sealed auctions keep every bid secret until close. Each bidder holds at most
+// one sealed bid, which they may revise as often as they like while the
+// auction is open. At close the highest bid wins (ties go to the earliest
+// bid) and the winner pays either their own bid (first-price) or the second
+// highest bid (Vickrey). A lone bidder in a Vickrey auction pays the reserve.
+
+// IsSealed reports whether bids in this type of auction are kept secret.
+func IsSealed(auctionType string) bool {
+	switch auctionType {
+	case AuctionTypeSealedFirstPrice, AuctionTypeVickrey, AuctionTypeUniformPrice:
+		return true
+	}
+	return false
+}
+
+// validateSealedBid checks a sealed bid; it only needs to be positive.
+func validateSealedBid(bid AuctionBid) error {
+	if bid.MaxAmount.IsPositive() {
+		return fmt.Errorf("sealed auctions do not accept maximum bids")
+	}
+	if !bid.Amount.IsPositive() {
+		return fmt.Errorf("bid amount must be positive")
+	}
+	return nil
+}
+
+// reviseSealedBid replaces any existing bid from the same bidder. Revised bids
+// move to the back of the line for the purposes of breaking ties.
+func reviseSealedBid(bids []AuctionBid, bid AuctionBid) []AuctionBid {
+	revised := make([]AuctionBid, 0, len(bids)+1)
+	for _, b := range bids {
+		if b.Bidder != bid.Bidder {
+			revised = append(revised, b)
+		}
+	}
+	return append(revised, bid)
+}
+
+// settleSealedBids returns the winning bid and the price the winner pays. The
+// returned bool is false if there were no bids at all.
+func settleSealedBids(r RunAuctionWFRequest, bids []AuctionBid) (AuctionBid, Money, bool) {
+	if len(bids) == 0 {
+		return AuctionBid{}, Money{}, false
+	}
+	winner := bids[0]
+	second := Money{}
+	for _, b := range bids[1:] {
+		if b.Amount.Cmp(winner.Amount) > 0 {
+			second = winner.Amount
+			winner = b
+		} else if b.Amount.Cmp(second) > 0 {
+			second = b.Amount
+		}
+	}
+	if r.Type == AuctionTypeVickrey {
+		return winner, minMoney(winner.Amount, maxMoney(second, r.ReservePrice)), true
+	}
+	return winner, winner.Amount, true
+}
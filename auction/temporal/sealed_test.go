@@ -0,0 +1,94 @@
+package temporal
+
+import "testing"
+
+func TestSettleSealedBids(t *testing.T) {
+	bid := func(bidder string, amount float64) AuctionBid {
+		return AuctionBid{Bidder: bidder, Amount: usd(amount)}
+	}
+	tests := []struct {
+		name       string
+		auction    string
+		reserve    float64
+		bids       []AuctionBid
+		wantBidder string
+		wantPrice  Money
+	}{
+		{
+			name:       "first price pays own bid",
+			auction:    AuctionTypeSealedFirstPrice,
+			bids:       []AuctionBid{bid("alice", 50), bid("bob", 70), bid("carol", 60)},
+			wantBidder: "bob",
+			wantPrice:  usd(70),
+		},
+		{
+			name:       "vickrey pays the second highest bid",
+			auction:    AuctionTypeVickrey,
+			bids:       []AuctionBid{bid("alice", 50), bid("bob", 70), bid("carol", 60)},
+			wantBidder: "bob",
+			wantPrice:  usd(60),
+		},
+		{
+			name:       "vickrey second highest bid comes first",
+			auction:    AuctionTypeVickrey,
+			bids:       []AuctionBid{bid("alice", 60), bid("bob", 70), bid("carol", 50)},
+			wantBidder: "bob",
+			wantPrice:  usd(60),
+		},
+		{
+			name:       "lone vickrey bidder pays the reserve",
+			auction:    AuctionTypeVickrey,
+			reserve:    30,
+			bids:       []AuctionBid{bid("alice", 50)},
+			wantBidder: "alice",
+			wantPrice:  usd(30),
+		},
+		{
+			name:       "vickrey pays the reserve over a lower second bid",
+			auction:    AuctionTypeVickrey,
+			reserve:    30,
+			bids:       []AuctionBid{bid("alice", 50), bid("bob", 20)},
+			wantBidder: "alice",
+			wantPrice:  usd(30),
+		},
+		{
+			name:       "vickrey never pays more than the winning bid",
+			auction:    AuctionTypeVickrey,
+			reserve:    80,
+			bids:       []AuctionBid{bid("alice", 50), bid("bob", 20)},
+			wantBidder: "alice",
+			wantPrice:  usd(50),
+		},
+		{
+			name:       "ties go to the earliest bid",
+			auction:    AuctionTypeVickrey,
+			bids:       []AuctionBid{bid("alice", 70), bid("bob", 70)},
+			wantBidder: "alice",
+			wantPrice:  usd(70),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := RunAuctionWFRequest{Type: tt.auction, ReservePrice: usd(tt.reserve)}
+			winner, price, ok := settleSealedBids(r, tt.bids)
+			if !ok || winner.Bidder != tt.wantBidder || price != tt.wantPrice {
+				t.Errorf("got %s paying %s (%v), want %s paying %s", winner.Bidder, price, ok, tt.wantBidder, tt.wantPrice)
+			}
+		})
+	}
+
+	if _, _, ok := settleSealedBids(RunAuctionWFRequest{Type: AuctionTypeVickrey}, nil); ok {
+		t.Errorf("settled an auction without bids")
+	}
+}
+
+func TestReviseSealedBid(t *testing.T) {
+	bids := []AuctionBid{
+		{Bidder: "alice", Amount: usd(50)},
+		{Bidder: "bob", Amount: usd(60)},
+	}
+	bids = reviseSealedBid(bids, AuctionBid{Bidder: "alice", Amount: usd(55)})
+	if len(bids) != 2 || bids[0].Bidder != "bob" || bids[1].Bidder != "alice" || bids[1].Amount != usd(55) {
+		t.Errorf("revised bids = %v, want bob's bid then alice's revised bid", bids)
+	}
+}
@@ -0,0 +1,136 @@
+package temporal
+
+import (
+	"errors"
+	"time"
+
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/workflow"
+)
+
+// Once an auction sells, the workflow settles it by capturing payment from the
+// winner. If the winner doesn't pay before the payment deadline, the item is
+// offered to the next highest bidder at their own best bid, and so on down the
+// leaderboard until someone pays or we run out of bidders at or above the
+// reserve. Multi-unit auctions capture each allocation but make no
+// second-chance offers. Settlement gives up as soon as it learns the worker
+// has no payment processor, since no later capture could succeed either.
+
+const (
+	// settlement statuses
+	SettlementStatusPaid          = "paid"
+	SettlementStatusPartiallyPaid = "partially_paid"
+	SettlementStatusUnpaid        = "unpaid"
+
+	defaultPaymentDeadline = 24 * time.Hour
+)
+
+// PaymentAttempt records one attempt to capture payment from a bidder.
+type PaymentAttempt struct {
+	Bidder  string          `json:"bidder"`
+	Amount  Money           `json:"amount"`
+	Receipt *PaymentReceipt `json:"receipt,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// AuctionSettlement is the outcome of collecting payment for a sold auction.
+type AuctionSettlement struct {
+	Status   string           `json:"status"`
+	Bidder   string           `json:"bidder,omitempty"`
+	Amount   Money            `json:"amount"`
+	Attempts []PaymentAttempt `json:"attempts"`
+}
+
+// secondChanceOffers returns the bidders to offer the item to, in order,
+// starting with the winner at the sale price.
+func secondChanceOffers(r RunAuctionWFRequest, s AuctionState, winner AuctionBid, price Money) []PaymentRequest {
+	offers := []PaymentRequest{{Item: r.Item, Bidder: winner.Bidder, Amount: price}}
+	if r.Type == AuctionTypeDutch {
+		return offers
+	}
+	for _, st := range rankBidders(r.Type, s.Bids, s.TopBid) {
+		if st.Bidder == winner.Bidder || st.Amount.Cmp(r.ReservePrice) < 0 {
+			continue
+		}
+		offers = append(offers, PaymentRequest{Item: r.Item, Bidder: st.Bidder, Amount: st.Amount})
+	}
+	return offers
+}
+
+// settleAuction collects payment for a sold auction.
+func settleAuction(ctx workflow.Context, r RunAuctionWFRequest, s AuctionState, result AuctionResult) *AuctionSettlement {
+	deadline := r.PaymentDeadline
+	if deadline <= 0 {
+		deadline = defaultPaymentDeadline
+	}
+	ctx = workflow.WithActivityOptions(ctx, workflow.ActivityOptions{
+		ScheduleToCloseTimeout: deadline,
+		StartToCloseTimeout:    time.Minute,
+		RetryPolicy: &temporal.RetryPolicy{
+			InitialInterval:        time.Second,
+			BackoffCoefficient:     2.0,
+			MaximumInterval:        time.Minute,
+			NonRetryableErrorTypes: []string{ErrTypePaymentDeclined, ErrTypeNoPaymentProcessor},
+		},
+	})
+	var a *Activities
+	noProcessor := false
+	capture := func(req PaymentRequest) PaymentAttempt {
+		attempt := PaymentAttempt{Bidder: req.Bidder, Amount: req.Amount}
+		var receipt PaymentReceipt
+		if err := workflow.ExecuteActivity(ctx, a.CapturePayment, req).Get(ctx, &receipt); err != nil {
+			var appErr *temporal.ApplicationError
+			noProcessor = errors.As(err, &appErr) && appErr.Type() == ErrTypeNoPaymentProcessor
+			attempt.Error = err.Error()
+			return attempt
+		}
+		attempt.Receipt = &receipt
+		return attempt
+	}
+
+	settlement := &AuctionSettlement{Status: SettlementStatusUnpaid, Attempts: []PaymentAttempt{}}
+
+	// multi-unit auctions capture every allocation
+	if len(result.Allocations) > 0 {
+		paid := 0
+		for _, alloc := range result.Allocations {
+			attempt := capture(PaymentRequest{
+				Item:   r.Item,
+				Bidder: alloc.Bidder,
+				Amount: alloc.Price.Mul(alloc.Quantity),
+			})
+			settlement.Attempts = append(settlement.Attempts, attempt)
+			if attempt.Receipt != nil {
+				paid++
+				settlement.Amount = settlement.Amount.Add(attempt.Amount)
+			}
+			if noProcessor {
+				break
+			}
+		}
+		switch paid {
+		case len(result.Allocations):
+			settlement.Status = SettlementStatusPaid
+		case 0:
+		default:
+			settlement.Status = SettlementStatusPartiallyPaid
+		}
+		return settlement
+	}
+
+	// single item auctions work down the list of second-chance offers
+	for _, offer := range secondChanceOffers(r, s, result.WinningBid, result.Price) {
+		attempt := capture(offer)
+		settlement.Attempts = append(settlement.Attempts, attempt)
+		if attempt.Receipt != nil {
+			settlement.Status = SettlementStatusPaid
+			settlement.Bidder = attempt.Bidder
+			settlement.Amount = attempt.Amount
+			break
+		}
+		if noProcessor {
+			break
+		}
+	}
+	return settlement
+}
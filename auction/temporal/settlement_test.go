@@ -0,0 +1,83 @@
+package temporal
+
+import "time"
+
+func (s *AuctionWorkflowSuite) Test_SettlementCapturesPayment() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(60)})
+
+	result := s.run(RunAuctionWFRequest{Item: "vase", Duration: time.Hour, ReservePrice: usd(25), Webhook: "http://localhost:8080/webhook"})
+
+	s.Require().NotNil(result.Settlement)
+	s.Equal(SettlementStatusPaid, result.Settlement.Status)
+	s.Equal("alice", result.Settlement.Bidder)
+	s.Equal(usd(60), result.Settlement.Amount)
+	s.Require().Len(result.Settlement.Attempts, 1)
+	s.Require().NotNil(result.Settlement.Attempts[0].Receipt)
+	s.Equal(usd(60), result.Settlement.Attempts[0].Receipt.Amount)
+}
+
+func (s *AuctionWorkflowSuite) Test_SettlementSecondChanceOffer() {
+	s.payments.DeclinedBidders = []string{"alice"}
+	s.bidAt(time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(50)})
+	s.bidAt(3*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(60)})
+
+	result := s.run(RunAuctionWFRequest{Item: "vase", Duration: time.Hour, ReservePrice: usd(25), Webhook: "http://localhost:8080/webhook"})
+
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.Require().NotNil(result.Settlement)
+	s.Equal(SettlementStatusPaid, result.Settlement.Status)
+	s.Equal("carol", result.Settlement.Bidder)
+	s.Equal(usd(50), result.Settlement.Amount)
+	s.Require().Len(result.Settlement.Attempts, 2)
+	s.Equal("alice", result.Settlement.Attempts[0].Bidder)
+	s.Nil(result.Settlement.Attempts[0].Receipt)
+	s.Contains(result.Settlement.Attempts[0].Error, "declined")
+}
+
+func (s *AuctionWorkflowSuite) Test_SettlementSkipsBidsBelowReserve() {
+	s.payments.DeclinedBidders = []string{"alice"}
+	s.bidAt(time.Minute, AuctionBid{Bidder: "bob", Amount: usd(20)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(60)})
+
+	result := s.run(RunAuctionWFRequest{Item: "vase", Duration: time.Hour, ReservePrice: usd(25), Webhook: "http://localhost:8080/webhook"})
+
+	s.Require().NotNil(result.Settlement)
+	s.Equal(SettlementStatusUnpaid, result.Settlement.Status)
+	s.Len(result.Settlement.Attempts, 1)
+}
+
+func (s *AuctionWorkflowSuite) Test_SettlementPartiallyPaid() {
+	s.payments.DeclinedBidders = []string{"bob"}
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Quantity: 2, Amount: usd(20)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Quantity: 1, Amount: usd(15)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "tickets",
+		Type:         AuctionTypeUniformPrice,
+		Quantity:     3,
+		Duration:     time.Hour,
+		ReservePrice: usd(10),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Equal(usd(15), result.Price)
+	s.Require().NotNil(result.Settlement)
+	s.Equal(SettlementStatusPartiallyPaid, result.Settlement.Status)
+	s.Equal(usd(30), result.Settlement.Amount)
+	s.Len(result.Settlement.Attempts, 2)
+}
+
+func (s *AuctionWorkflowSuite) Test_SettlementWithoutPaymentProcessor() {
+	s.activities.Payments = nil
+	s.bidAt(time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(60)})
+
+	result := s.run(RunAuctionWFRequest{Item: "vase", Duration: time.Hour, ReservePrice: usd(25), Webhook: "http://localhost:8080/webhook"})
+
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Require().NotNil(result.Settlement)
+	s.Equal(SettlementStatusUnpaid, result.Settlement.Status)
+	s.Require().Len(result.Settlement.Attempts, 1)
+	s.Contains(result.Settlement.Attempts[0].Error, "no payment processor")
+}
@@ -0,0 +1,36 @@
+package temporal
+
+import (
+	"github.com/brojonat/temporal-examples/convenience"
+)
+
+// The auction records a timeline of its top bid so that the course of the
+// auction can be charted. A point is recorded when the auction opens, on
+// every bid and price drop, and when sealed bids are opened at close. Like
+// the bid log, the timeline of a sealed auction is kept secret until close.
+
+const (
+	// query types
+	QueryTypeTimeline = "timeline"
+)
+
+// AuctionSnapshot is a point on the auction's timeline. Amount is the top bid,
+// or the asking price of a dutch auction nobody has taken yet (or the clearing
+// price of a uniform price auction once it closes).
+type AuctionSnapshot struct {
+	Amount   Money  `json:"amount"`
+	Bidder   string `json:"bidder"`
+	BidCount int    `json:"bid_count"`
+}
+
+// AuctionTimeline is the recorded history of an auction.
+type AuctionTimeline = convenience.Timeline[AuctionSnapshot]
+
+// auctionSnapshot returns the current point on the auction's timeline.
+func auctionSnapshot(r RunAuctionWFRequest, s AuctionState) AuctionSnapshot {
+	snap := AuctionSnapshot{Amount: s.TopBid.Amount, Bidder: s.TopBid.Bidder, BidCount: s.BidCount}
+	if (r.Type == AuctionTypeDutch && s.TopBid.Bidder == "") || r.Type == AuctionTypeUniformPrice {
+		snap.Amount = s.Price
+	}
+	return snap
+}
@@ -0,0 +1,75 @@
+package temporal
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Uniform-price auctions sell Quantity identical units. Each bidder holds one
+// sealed bid for some number of units at a price per unit, which they may
+// revise until close. At close, units are allocated to the highest bids
+// (ties go to the earliest bid, and the marginal bidder may be partially
+// filled) and every winner pays the same clearing price per unit.
+
+const (
+	// uniform price rules
+	PricingRuleLowestWinning = "lowest_winning"
+	PricingRuleHighestLosing = "highest_losing"
+)
+
+// AuctionAllocation is the number of units awarded to a bidder and the price
+// they pay per unit.
+type AuctionAllocation struct {
+	Bidder   string `json:"bidder"`
+	Quantity int    `json:"quantity"`
+	Price    Money  `json:"price"`
+}
+
+// validateUniformBid checks that a bid asks for a sensible number of units. A
+// bid that leaves its quantity unset (zero) asks for a single unit.
+func validateUniformBid(r RunAuctionWFRequest, bid AuctionBid) error {
+	if err := validateSealedBid(bid); err != nil {
+		return err
+	}
+	if bid.Quantity == 0 {
+		bid.Quantity = 1
+	}
+	if bid.Quantity < 1 || bid.Quantity > r.Quantity {
+		return fmt.Errorf("bid quantity must be between 1 and %d", r.Quantity)
+	}
+	return nil
+}
+
+// allocateUniformPrice allocates units to the highest bids at or above the
+// reserve and returns the allocations along with the clearing price.
+func allocateUniformPrice(r RunAuctionWFRequest, bids []AuctionBid) ([]AuctionAllocation, Money) {
+	ranked := slices.Clone(bids)
+	slices.SortStableFunc(ranked, func(a, b AuctionBid) int {
+		return b.Amount.Cmp(a.Amount)
+	})
+
+	allocations := []AuctionAllocation{}
+	remaining := r.Quantity
+	lowestWinning := Money{}
+	highestLosing := Money{}
+	for _, b := range ranked {
+		qty := max(b.Quantity, 1)
+		if remaining == 0 || b.Amount.Cmp(r.ReservePrice) < 0 {
+			highestLosing = maxMoney(highestLosing, b.Amount)
+			continue
+		}
+		qty = min(qty, remaining)
+		remaining -= qty
+		lowestWinning = b.Amount
+		allocations = append(allocations, AuctionAllocation{Bidder: b.Bidder, Quantity: qty})
+	}
+
+	price := lowestWinning
+	if r.PricingRule == PricingRuleHighestLosing {
+		price = minMoney(lowestWinning, maxMoney(highestLosing, r.ReservePrice))
+	}
+	for i := range allocations {
+		allocations[i].Price = price
+	}
+	return allocations, price
+}
@@ -0,0 +1,104 @@
+package temporal
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestAllocateUniformPrice(t *testing.T) {
+	bid := func(bidder string, quantity int, amount float64) AuctionBid {
+		return AuctionBid{Bidder: bidder, Quantity: quantity, Amount: usd(amount)}
+	}
+	alloc := func(bidder string, quantity int, price float64) AuctionAllocation {
+		return AuctionAllocation{Bidder: bidder, Quantity: quantity, Price: usd(price)}
+	}
+	tests := []struct {
+		name      string
+		rule      string
+		quantity  int
+		bids      []AuctionBid
+		want      []AuctionAllocation
+		wantPrice Money
+	}{
+		{
+			name:      "lowest winning bid sets the price",
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 3, 20), bid("b", 2, 15), bid("c", 2, 12), bid("d", 1, 8)},
+			want:      []AuctionAllocation{alloc("a", 3, 15), alloc("b", 2, 15)},
+			wantPrice: usd(15),
+		},
+		{
+			name:      "highest losing bid sets the price",
+			rule:      PricingRuleHighestLosing,
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 3, 20), bid("b", 2, 15), bid("c", 2, 12), bid("d", 1, 8)},
+			want:      []AuctionAllocation{alloc("a", 3, 12), alloc("b", 2, 12)},
+			wantPrice: usd(12),
+		},
+		{
+			name:      "highest losing price is at least the reserve",
+			rule:      PricingRuleHighestLosing,
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 3, 20), bid("b", 2, 15), bid("d", 1, 8)},
+			want:      []AuctionAllocation{alloc("a", 3, 10), alloc("b", 2, 10)},
+			wantPrice: usd(10),
+		},
+		{
+			name:      "marginal bidder is partially filled",
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 3, 20), bid("b", 4, 15)},
+			want:      []AuctionAllocation{alloc("a", 3, 15), alloc("b", 2, 15)},
+			wantPrice: usd(15),
+		},
+		{
+			name:      "ties go to the earliest bid",
+			quantity:  4,
+			bids:      []AuctionBid{bid("a", 3, 15), bid("b", 3, 15)},
+			want:      []AuctionAllocation{alloc("a", 3, 15), alloc("b", 1, 15)},
+			wantPrice: usd(15),
+		},
+		{
+			name:      "unset quantity asks for one unit",
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 0, 20)},
+			want:      []AuctionAllocation{alloc("a", 1, 20)},
+			wantPrice: usd(20),
+		},
+		{
+			name:      "bids below the reserve lose",
+			quantity:  5,
+			bids:      []AuctionBid{bid("a", 3, 9), bid("b", 2, 8)},
+			want:      []AuctionAllocation{},
+			wantPrice: Money{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := RunAuctionWFRequest{Quantity: tt.quantity, ReservePrice: usd(10), PricingRule: tt.rule}
+			got, price := allocateUniformPrice(r, tt.bids)
+			if !slices.Equal(got, tt.want) || price != tt.wantPrice {
+				t.Errorf("got %v at %s, want %v at %s", got, price, tt.want, tt.wantPrice)
+			}
+		})
+	}
+}
+
+func TestValidateUniformBid(t *testing.T) {
+	r := RunAuctionWFRequest{Quantity: 5}
+	tests := []struct {
+		quantity int
+		wantErr  bool
+	}{
+		{quantity: 0},
+		{quantity: 1},
+		{quantity: 5},
+		{quantity: 6, wantErr: true},
+		{quantity: -1, wantErr: true},
+	}
+	for _, tt := range tests {
+		err := validateUniformBid(r, AuctionBid{Bidder: "a", Quantity: tt.quantity, Amount: usd(10)})
+		if (err != nil) != tt.wantErr {
+			t.Errorf("validateUniformBid(quantity %d) = %v, want error: %v", tt.quantity, err, tt.wantErr)
+		}
+	}
+}
@@ -1,6 +1,9 @@
 package temporal
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"time"
 
 	"go.temporal.io/sdk/temporal"
@@ -10,65 +13,790 @@ import (
 // WorkflowAuction is a workflow that runs for some specified time and receives
 // incoming bids. The current top bid is queryable. At the end of the auction,
 // the workflow sends the results via HTTP (i.e., webhook) until it receives a
-// 200.
+// 200. The results report whether the item sold, whether the reserve price
+// was not met, or whether no bids were received at all.
+//
+// Auctions may optionally "soft close": a bid that takes the lead within the
+// final SoftCloseWindow of the auction pushes the end time out by
+// SoftCloseExtension, but never past the MaxDuration hard cap (if set).
+//
+// Bids can be submitted either as a signal (fire and forget) or as an update,
+// in which case invalid bids are rejected back to the caller with a reason.
+// Bidders may also submit a hidden maximum and let the workflow bid on their
+// behalf (see proxy.go); only the visible price is ever exposed.
+//
+// Dutch auctions run the other way around: the price starts at StartPrice and
+// drops by PriceStep every PriceInterval (but never below the reserve). The
+// first bidder to accept the current price wins immediately.
+//
+// Sealed first-price and Vickrey (second-price) auctions hide every bid until
+// close (see sealed.go); the state query only reports the bid count and end
+// time while the auction is open.
+//
+// Uniform-price auctions sell several identical units at once; bids are sealed
+// in the same way and every winner pays a single clearing price (see
+// uniform.go).
+//
+// Every accepted bid is kept in an ordered bid log that can be paged through
+// with the bids query, and the leaderboard query ranks each bidder's best bid
+// (see history.go). Busy auctions continue as new to keep the history bounded.
+//
+// English auctions may also offer a buy-it-now price that ends the auction
+// immediately (see buynow.go).
+//
+// Sellers can manage a live auction through the cancel, extend and close_now
+// updates. A cancelled auction sends a cancellation to CancelWebhook instead
+// of sending its results.
+//
+// Bidders who lose the lead of an english auction are sent a coalesced
+// "outbid" notification (see notify.go).
+//
+// Auctions may require bidders to register; each bid is then committed against
+// the bidder's credit limit before it's accepted (see bidder.go).
+//
+// Sold auctions are settled before the results are sent: payment is captured
+// from the winner, falling back to second-chance offers to the runners up if
+// the winner doesn't pay before PaymentDeadline (see settlement.go).
+//
+// An auction with a StartTime in the future is scheduled: it reports when it
+// opens, rejects bids sent as updates and buffers bids sent as signals until
+// then. The auction's Duration is measured from StartTime.
+//
+// Auctions can also be run in bulk as the lots of a catalog (see catalog.go).
 
 const (
 	// query types
-	QueryTypeState = "state"
+	QueryTypeState   = "state"
+	QueryTypeVersion = "version"
 
 	// signal types
 	SignalTypeBid = "bid"
+
+	// update types
+	UpdateTypeBid      = "bid"
+	UpdateTypeCancel   = "cancel"
+	UpdateTypeExtend   = "extend"
+	UpdateTypeCloseNow = "close_now"
+
+	UpdateTypeWaitVersion = "wait_version"
+
+	// how long a wait_version update waits for the state to change before
+	// returning the unchanged version
+	VersionWaitTimeout = 15 * time.Second
+
+	// auction types
+	AuctionTypeEnglish = "english"
+	AuctionTypeDutch   = "dutch"
+
+	AuctionTypeSealedFirstPrice = "sealed_first_price"
+	AuctionTypeVickrey          = "vickrey"
+	AuctionTypeUniformPrice     = "uniform_price"
+
+	// reasons an auction closed
+	CloseReasonEndTime       = "end_time"
+	CloseReasonBuyNow        = "buy_now"
+	CloseReasonPriceAccepted = "price_accepted"
+	CloseReasonClosedEarly   = "closed_early"
+
+	// auction outcomes
+	AuctionOutcomeSold          = "sold"
+	AuctionOutcomeReserveNotMet = "reserve_not_met"
+	AuctionOutcomeNoBids        = "no_bids"
+	AuctionOutcomeCancelled     = "cancelled"
+
+	// auction statuses
+	AuctionStatusScheduled = "scheduled"
+	AuctionStatusOpen      = "open"
+	AuctionStatusClosed    = "closed"
 )
 
 type QueryResultState struct {
-	Bidder string  `json:"bidder"`
-	Amount float64 `json:"amount"`
+	Type         string    `json:"type"`
+	Status       string    `json:"status"`
+	StartTime    time.Time `json:"start_time"`
+	Bidder       string    `json:"bidder"`
+	Amount       Money     `json:"amount"`
+	EndTime      time.Time `json:"end_time"`
+	Price        Money     `json:"price"`
+	NextDropTime time.Time `json:"next_drop_time"`
+	BidCount     int       `json:"bid_count"`
+	Quantity     int       `json:"quantity"`
+	BuyNowPrice  Money     `json:"buy_now_price"`
+	Closed       bool      `json:"closed"`
+	Version      int       `json:"version"`
 }
 
 type RunAuctionWFRequest struct {
 	StartTime    time.Time     `json:"start_time"`
 	Duration     time.Duration `json:"duration"`
 	Item         string        `json:"item"`
-	ReservePrice float64       `json:"reserve_price"`
+	Currency     string        `json:"currency"`
+	ReservePrice Money         `json:"reserve_price"`
 	Webhook      string        `json:"webhook"`
+	// Catalog is set on auctions run as the lots of a catalog, which report
+	// their results to the catalog rather than to a webhook.
+	Catalog            string        `json:"catalog,omitempty"`
+	CancelWebhook      string        `json:"cancel_webhook"`
+	NotifyWebhook      string        `json:"notify_webhook"`
+	NotifyDebounce     time.Duration `json:"notify_debounce"`
+	PaymentDeadline    time.Duration `json:"payment_deadline"`
+	SoftCloseWindow    time.Duration `json:"soft_close_window"`
+	SoftCloseExtension time.Duration `json:"soft_close_extension"`
+	MaxDuration        time.Duration `json:"max_duration"`
+	MinIncrement       Money         `json:"min_increment"`
+	Type               string        `json:"type"`
+	StartPrice         Money         `json:"start_price"`
+	PriceStep          Money         `json:"price_step"`
+	PriceInterval      time.Duration `json:"price_interval"`
+	Quantity           int           `json:"quantity"`
+	PricingRule        string        `json:"pricing_rule"`
+	BuyNowPrice        Money         `json:"buy_now_price"`
+	// BuyNowDisableFraction is the fraction of the reserve price the bidding
+	// must reach before the buy-it-now option is withdrawn.
+	BuyNowDisableFraction float64 `json:"buy_now_disable_fraction"`
+	// RequireRegistration only accepts bids from registered bidders whose
+	// credit limit covers the bid (see bidder.go).
+	RequireRegistration bool          `json:"require_registration"`
+	State               *AuctionState `json:"state,omitempty"`
+}
+
+// Validate checks that the request describes a runnable auction.
+func (r RunAuctionWFRequest) Validate() error {
+	r, err := r.withCurrency()
+	if err != nil {
+		return err
+	}
+	if r.Item == "" {
+		return fmt.Errorf("must supply an item")
+	}
+	if r.Duration <= 0 {
+		return fmt.Errorf("duration must be positive")
+	}
+	if r.Webhook == "" && r.Catalog == "" {
+		return fmt.Errorf("must supply a webhook")
+	}
+	if r.SoftCloseWindow < 0 || r.SoftCloseExtension < 0 {
+		return fmt.Errorf("soft close window and extension cannot be negative")
+	}
+	if r.MaxDuration > 0 && r.MaxDuration < r.Duration {
+		return fmt.Errorf("max duration must be at least the auction duration")
+	}
+	switch r.Type {
+	case "", AuctionTypeEnglish, AuctionTypeSealedFirstPrice, AuctionTypeVickrey:
+	case AuctionTypeDutch:
+		if !r.StartPrice.IsPositive() || !r.PriceStep.IsPositive() || r.PriceInterval <= 0 {
+			return fmt.Errorf("dutch auctions require a start price, price step and price interval")
+		}
+		if r.StartPrice.Cmp(r.ReservePrice) < 0 {
+			return fmt.Errorf("start price cannot be below the reserve price")
+		}
+	case AuctionTypeUniformPrice:
+		if r.Quantity < 1 {
+			return fmt.Errorf("uniform price auctions require a quantity of at least 1")
+		}
+		switch r.PricingRule {
+		case "", PricingRuleLowestWinning, PricingRuleHighestLosing:
+		default:
+			return fmt.Errorf("unknown pricing rule %q", r.PricingRule)
+		}
+	default:
+		return fmt.Errorf("unknown auction type %q", r.Type)
+	}
+	if r.Quantity > 1 && r.Type != AuctionTypeUniformPrice {
+		return fmt.Errorf("only uniform price auctions can sell more than one unit")
+	}
+	if r.BuyNowPrice.IsPositive() {
+		if r.Type != "" && r.Type != AuctionTypeEnglish {
+			return fmt.Errorf("only english auctions can offer a buy-it-now price")
+		}
+		if r.BuyNowPrice.Cmp(r.ReservePrice) < 0 {
+			return fmt.Errorf("buy-it-now price cannot be below the reserve price")
+		}
+		if r.BuyNowDisableFraction < 0 || r.BuyNowDisableFraction > 1 {
+			return fmt.Errorf("buy-it-now disable fraction must be between 0 and 1")
+		}
+	}
+	return nil
 }
 
 type AuctionBid struct {
-	Item   string  `json:"item"`
-	Bidder string  `json:"bidder"`
-	Amount float64 `json:"amount"`
+	Item      string `json:"item"`
+	Bidder    string `json:"bidder"`
+	Amount    Money  `json:"amount"`
+	MaxAmount Money  `json:"max_amount"`
+
+	// Quantity is the number of units bid for in uniform price auctions; it
+	// defaults to a single unit when left unset
+	Quantity int `json:"quantity,omitempty"`
+
+	// CallbackURL receives outbid notifications for this bidder
+	CallbackURL string `json:"callback_url,omitempty"`
+}
+
+// BidResult is returned to bidders whose bid was accepted via update. An
+// accepted bid may still be outbid immediately by another bidder's maximum.
+type BidResult struct {
+	Accepted bool       `json:"accepted"`
+	Leading  bool       `json:"leading"`
+	TopBid   AuctionBid `json:"top_bid"`
+	EndTime  time.Time  `json:"end_time"`
 }
 
-func RunAuctionWF(ctx workflow.Context, r RunAuctionWFRequest) error {
-	// register a handler to return the current top bid
-	topBid := AuctionBid{Item: r.Item}
-	err := workflow.SetQueryHandler(ctx, QueryTypeState, func() (AuctionBid, error) {
-		return topBid, nil
+// AuctionResult is the payload sent to the webhook when the auction closes.
+type AuctionResult struct {
+	Item         string              `json:"item"`
+	Outcome      string              `json:"outcome"`
+	WinningBid   AuctionBid          `json:"winning_bid"`
+	Price        Money               `json:"price"`
+	ReservePrice Money               `json:"reserve_price"`
+	BidCount     int                 `json:"bid_count"`
+	ClosedAt     time.Time           `json:"closed_at"`
+	CloseReason  string              `json:"close_reason"`
+	Allocations  []AuctionAllocation `json:"allocations,omitempty"`
+	Settlement   *AuctionSettlement  `json:"settlement,omitempty"`
+}
+
+// AuctionCancellation is the payload sent to the cancel webhook when the
+// seller cancels the auction.
+type AuctionCancellation struct {
+	Item        string    `json:"item"`
+	Reason      string    `json:"reason"`
+	BidCount    int       `json:"bid_count"`
+	CancelledAt time.Time `json:"cancelled_at"`
+}
+
+// auctionStatus describes where the auction is in its lifecycle
+func auctionStatus(scheduled, closed bool) string {
+	switch {
+	case closed:
+		return AuctionStatusClosed
+	case scheduled:
+		return AuctionStatusScheduled
+	}
+	return AuctionStatusOpen
+}
+
+// softCloseEndTime returns the end time of the auction after a new top bid
+// arrives at time now. A bid inside the soft close window pushes the end time
+// out by the extension, clamped to the hard cap.
+func softCloseEndTime(r RunAuctionWFRequest, start, end, now time.Time) time.Time {
+	if r.SoftCloseWindow <= 0 || r.SoftCloseExtension <= 0 {
+		return end
+	}
+	if end.Sub(now) > r.SoftCloseWindow {
+		return end
+	}
+	newEnd := end.Add(r.SoftCloseExtension)
+	if r.MaxDuration > 0 && newEnd.After(start.Add(r.MaxDuration)) {
+		newEnd = start.Add(r.MaxDuration)
+	}
+	if newEnd.Before(end) {
+		return end
+	}
+	return newEnd
+}
+
+// AuctionState is the mutable state of a running auction. It is carried over
+// to the next run when the workflow continues as new.
+type AuctionState struct {
+	StartTime    time.Time          `json:"start_time"`
+	EndTime      time.Time          `json:"end_time"`
+	TopBid       AuctionBid         `json:"top_bid"`
+	LeaderMax    Money              `json:"leader_max"`
+	SealedBids   []AuctionBid       `json:"sealed_bids"`
+	Price        Money              `json:"price"`
+	NextDropTime time.Time          `json:"next_drop_time"`
+	BidCount     int                `json:"bid_count"`
+	Version      int                `json:"version"`
+	Bids         []AuctionBidRecord `json:"bids"`
+
+	// PendingOutbid holds notifications waiting for the debounce window
+	PendingOutbid map[string]OutbidNotification `json:"pending_outbid"`
+
+	// Committed holds the credit each bidder has committed to this auction;
+	// CommitSeq numbers the commitments
+	Committed map[string]BidderCommitment `json:"committed"`
+	CommitSeq int                         `json:"commit_seq"`
+
+	// Timeline charts the top bid over the course of the auction
+	Timeline AuctionTimeline `json:"timeline"`
+}
+
+func RunAuctionWF(ctx workflow.Context, r RunAuctionWFRequest) (AuctionResult, error) {
+	if err := r.Validate(); err != nil {
+		return AuctionResult{}, temporal.NewNonRetryableApplicationError(err.Error(), "InvalidRequest", err)
+	}
+	if r.Type == "" {
+		r.Type = AuctionTypeEnglish
+	}
+	if r.Quantity < 1 {
+		r.Quantity = 1
+	}
+	r, _ = r.withCurrency()
+
+	// the auction is measured from StartTime, or from the time the workflow
+	// starts if that's later, unless we're picking up where a previous run
+	// left off
+	s := AuctionState{}
+	if r.State != nil {
+		s = *r.State
+	} else {
+		s.StartTime = workflow.Now(ctx)
+		if r.StartTime.After(s.StartTime) {
+			s.StartTime = r.StartTime
+		}
+		s.EndTime = s.StartTime.Add(r.Duration)
+		s.TopBid = AuctionBid{Item: r.Item, Amount: Money{Currency: r.Currency}}
+		s.Price = r.StartPrice
+	}
+	if s.PendingOutbid == nil {
+		s.PendingOutbid = map[string]OutbidNotification{}
+	}
+	if s.Committed == nil {
+		s.Committed = map[string]BidderCommitment{}
+	}
+
+	// initialization for main selector loop; closed is set as soon as the
+	// auction stops accepting bids, which may be before the loop exits
+	doLoop := true
+	scheduled := workflow.Now(ctx).Before(s.StartTime)
+	closed := false
+	closeReason := CloseReasonEndTime
+	cancelled := false
+	cancelReason := ""
+
+	// snapshot records the current top bid on the timeline
+	snapshot := func() {
+		s.Timeline.Record(workflow.Now(ctx), auctionSnapshot(r, s))
+	}
+	if r.State == nil && !scheduled {
+		snapshot()
+	}
+
+	// register a handler to return the current top bid; the leader's maximum
+	// is tracked separately and never exposed, and sealed bids are kept aside
+	// so the top bid stays empty until the auction has been settled
+	err := workflow.SetQueryHandler(ctx, QueryTypeState, func() (QueryResultState, error) {
+		buyNowPrice := Money{Currency: r.Currency}
+		if !closed && buyNowAvailable(r, s.TopBid) {
+			buyNowPrice = r.BuyNowPrice
+		}
+		return QueryResultState{
+			Type:         r.Type,
+			Status:       auctionStatus(scheduled, closed),
+			StartTime:    s.StartTime,
+			Bidder:       s.TopBid.Bidder,
+			Amount:       s.TopBid.Amount,
+			EndTime:      s.EndTime,
+			Price:        s.Price,
+			NextDropTime: s.NextDropTime,
+			BidCount:     s.BidCount,
+			Quantity:     r.Quantity,
+			BuyNowPrice:  buyNowPrice,
+			Closed:       closed,
+			Version:      s.Version,
+		}, nil
 	})
 	if err != nil {
-		return err
+		return AuctionResult{}, err
 	}
 
-	// initialization for main selector loop
-	doLoop := true
+	// register a handler to return the state version, which changes whenever
+	// the state does
+	err = workflow.SetQueryHandler(ctx, QueryTypeVersion, func() (int, error) {
+		return s.Version, nil
+	})
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// watchers long-poll for changes: the update blocks until the version
+	// moves past the one they last saw, or VersionWaitTimeout passes, and
+	// returns the current version. Waits end early once the run is finishing
+	// (or continuing as new) so they never hold it open.
+	finishing := false
+	err = workflow.SetUpdateHandler(ctx, UpdateTypeWaitVersion, func(ctx workflow.Context, seen int) (int, error) {
+		_, err := workflow.AwaitWithTimeout(ctx, VersionWaitTimeout, func() bool {
+			return s.Version > seen || finishing
+		})
+		return s.Version, err
+	})
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// register handlers to return the bid log and leaderboard
+	err = workflow.SetQueryHandler(ctx, QueryTypeBids, func(offset, limit int) (AuctionBidsPage, error) {
+		if err := checkBidsVisible(r.Type, closed); err != nil {
+			return AuctionBidsPage{}, err
+		}
+		return pageBids(s.Bids, offset, limit), nil
+	})
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	err = workflow.SetQueryHandler(ctx, QueryTypeLeaderboard, func() ([]AuctionStanding, error) {
+		if err := checkBidsVisible(r.Type, closed); err != nil {
+			return nil, err
+		}
+		return rankBidders(r.Type, s.Bids, s.TopBid), nil
+	})
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// register a handler to return the timeline of the top bid
+	err = workflow.SetQueryHandler(ctx, QueryTypeTimeline, func() (AuctionTimeline, error) {
+		if err := checkBidsVisible(r.Type, closed); err != nil {
+			return AuctionTimeline{}, err
+		}
+		return s.Timeline, nil
+	})
+	if err != nil {
+		return AuctionResult{}, err
+	}
 	var signal AuctionBid
 	selector := workflow.NewSelector(ctx)
 
-	// receive auction bids
+	// validateBid returns the reason a bid would be rejected, if any
+	validateBid := func(bid AuctionBid) error {
+		if closed {
+			return fmt.Errorf("auction for %s is closed", r.Item)
+		}
+		if scheduled {
+			return fmt.Errorf("auction for %s opens at %s", r.Item, s.StartTime.Format(time.RFC3339))
+		}
+		if bid.Bidder == "" {
+			return fmt.Errorf("bid must specify a bidder")
+		}
+		if bid.Quantity > 1 && r.Type != AuctionTypeUniformPrice {
+			return fmt.Errorf("this auction only sells a single unit")
+		}
+		bid, err := bidIn(r.Currency, bid)
+		if err != nil {
+			return err
+		}
+		switch {
+		case r.Type == AuctionTypeDutch:
+			return validateDutchBid(s.Price, bid)
+		case r.Type == AuctionTypeUniformPrice:
+			return validateUniformBid(r, bid)
+		case IsSealed(r.Type):
+			return validateSealedBid(bid)
+		case isBuyNowBid(r, s.TopBid, bid):
+			return nil
+		}
+		return validateProxyBid(r, s.TopBid, s.LeaderMax, bid)
+	}
+
+	// holdsBid reports whether the bidder's bid still stands
+	holdsBid := func(bidder string) bool {
+		if IsSealed(r.Type) {
+			return slices.ContainsFunc(s.SealedBids, func(b AuctionBid) bool { return b.Bidder == bidder })
+		}
+		return s.TopBid.Bidder == bidder
+	}
+
+	// releaseOutbid releases a bidder's credit commitment once their bid no
+	// longer stands; uses a separate goroutine so the release never blocks
+	// the auction. releasing counts the releases still being sent.
+	releasing := 0
+	releaseOutbid := func(bidder string) {
+		c, ok := s.Committed[bidder]
+		if !ok || holdsBid(bidder) {
+			return
+		}
+		delete(s.Committed, bidder)
+		releasing++
+		workflow.Go(ctx, func(ictx workflow.Context) {
+			defer func() { releasing-- }()
+			if err := releaseBid(ictx, c).Get(ictx, nil); err != nil {
+				workflow.GetLogger(ictx).Warn("could not release commitment", "bidder", bidder, "error", err)
+			}
+		})
+	}
+
+	// commit commits an amount of the bidder's credit to the auction
+	commit := func(ctx workflow.Context, c BidderCommitment) error {
+		s.CommitSeq++
+		c.Seq = s.CommitSeq
+		if err := commitBid(ctx, c); err != nil {
+			return err
+		}
+		s.Committed[c.Bidder] = c
+		return nil
+	}
+
+	// commitCredit commits a validated bid against the bidder's credit when
+	// the auction requires registration. The auction may have moved on while
+	// we waited, so the bid is checked again once the credit is committed; if
+	// it's no longer valid, a bidder whose earlier bid still stands gets the
+	// commitment for that bid back, and anyone else is released.
+	commitCredit := func(ctx workflow.Context, bid AuctionBid) error {
+		if !r.RequireRegistration {
+			return nil
+		}
+		c, err := bidCommitment(r, bid, 0)
+		if err != nil {
+			return err
+		}
+		prev, hadPrev := s.Committed[bid.Bidder]
+		if err := commit(ctx, c); err != nil {
+			return err
+		}
+		err = validateBid(bid)
+		if err == nil {
+			return nil
+		}
+		if hadPrev && holdsBid(bid.Bidder) {
+			if rerr := commit(ctx, prev); rerr != nil {
+				workflow.GetLogger(ctx).Warn("could not restore commitment", "bidder", bid.Bidder, "error", rerr)
+			}
+			return err
+		}
+		releaseOutbid(bid.Bidder)
+		return err
+	}
+
+	// applyBid logs a validated bid and records it, extending the auction if
+	// the lead changed hands; accepting the price in a dutch auction or buying
+	// it now ends it, and sealed bids are simply filed away until close
+	applyBid := func(bid AuctionBid) {
+		s.Version++
+		defer snapshot()
+		bid, _ = bidIn(r.Currency, bid)
+		bid.Item = r.Item
+		s.Bids = append(s.Bids, AuctionBidRecord{
+			Seq:  len(s.Bids) + 1,
+			Time: workflow.Now(ctx),
+			Bid:  bid,
+		})
+		if IsSealed(r.Type) {
+			s.SealedBids = reviseSealedBid(s.SealedBids, bid)
+			s.BidCount = len(s.SealedBids)
+			return
+		}
+		s.BidCount++
+		if r.Type == AuctionTypeDutch {
+			s.TopBid = AuctionBid{Item: r.Item, Bidder: bid.Bidder, Amount: s.Price}
+			closed = true
+			closeReason = CloseReasonPriceAccepted
+			return
+		}
+		if isBuyNowBid(r, s.TopBid, bid) {
+			s.TopBid = AuctionBid{Item: r.Item, Bidder: bid.Bidder, Amount: r.BuyNowPrice}
+			closed = true
+			closeReason = CloseReasonBuyNow
+			return
+		}
+		prevLeader := s.TopBid.Bidder
+		s.TopBid, s.LeaderMax = resolveProxyBid(r, s.TopBid, s.LeaderMax, bid)
+		if s.TopBid.Bidder != prevLeader {
+			s.EndTime = softCloseEndTime(r, s.StartTime, s.EndTime, workflow.Now(ctx))
+			delete(s.PendingOutbid, s.TopBid.Bidder)
+			if prevLeader != "" {
+				s.PendingOutbid[prevLeader] = OutbidNotification{
+					Event:     OutbidEventType,
+					Item:      r.Item,
+					Bidder:    prevLeader,
+					TopAmount: s.TopBid.Amount,
+					OutbidAt:  workflow.Now(ctx),
+				}
+			}
+		}
+		releaseOutbid(prevLeader)
+		releaseOutbid(bid.Bidder)
+	}
+
+	// placeBid commits credit to a bid and applies it. Bids are placed one at
+	// a time, since committing credit blocks and the bid has to be checked
+	// again against whatever happened in the meantime.
+	bidMu := workflow.NewMutex(ctx)
+	placeBid := func(ctx workflow.Context, bid AuctionBid) error {
+		if err := bidMu.Lock(ctx); err != nil {
+			return err
+		}
+		defer bidMu.Unlock()
+		if err := validateBid(bid); err != nil {
+			return err
+		}
+		if err := commitCredit(ctx, bid); err != nil {
+			return err
+		}
+		applyBid(bid)
+		return nil
+	}
+
+	// receive auction bids via update; invalid bids are rejected to the caller
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeBid,
+		func(ctx workflow.Context, bid AuctionBid) (BidResult, error) {
+			if err := placeBid(ctx, bid); err != nil {
+				return BidResult{}, err
+			}
+			if IsSealed(r.Type) {
+				return BidResult{Accepted: true, EndTime: s.EndTime}, nil
+			}
+			return BidResult{
+				Accepted: true,
+				Leading:  s.TopBid.Bidder == bid.Bidder,
+				TopBid:   s.TopBid,
+				EndTime:  s.EndTime,
+			}, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, bid AuctionBid) error {
+				return validateBid(bid)
+			},
+		},
+	)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// receive seller administration updates; these are rejected once the
+	// auction has closed
+	validateOpen := func() error {
+		if closed {
+			return fmt.Errorf("auction for %s is closed", r.Item)
+		}
+		return nil
+	}
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeCancel,
+		func(ctx workflow.Context, reason string) error {
+			closed = true
+			cancelled = true
+			cancelReason = reason
+			s.Version++
+			return nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, reason string) error {
+				return validateOpen()
+			},
+		},
+	)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeExtend,
+		func(ctx workflow.Context, d time.Duration) (time.Time, error) {
+			s.EndTime = s.EndTime.Add(d)
+			s.Version++
+			return s.EndTime, nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, d time.Duration) error {
+				if d <= 0 {
+					return fmt.Errorf("extension must be positive")
+				}
+				return validateOpen()
+			},
+		},
+	)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeCloseNow,
+		func(ctx workflow.Context) error {
+			closed = true
+			closeReason = CloseReasonClosedEarly
+			s.Version++
+			return nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context) error {
+				return validateOpen()
+			},
+		},
+	)
+	if err != nil {
+		return AuctionResult{}, err
+	}
+
+	// receive auction bids via signal; invalid bids are dropped. Committing
+	// credit blocks, so each bid is placed in a separate goroutine to keep
+	// the main loop responsive; placingSignals counts those still running.
+	placingSignals := 0
+	receiveBid := func(ctx workflow.Context, bid AuctionBid) {
+		if err := placeBid(ctx, bid); err != nil {
+			workflow.GetLogger(ctx).Info("dropping bid", "bidder", bid.Bidder, "reason", err)
+		}
+	}
 	bidChan := workflow.GetSignalChannel(ctx, SignalTypeBid)
 	selector.AddReceive(bidChan, func(c workflow.ReceiveChannel, more bool) {
 		c.Receive(ctx, &signal)
-		if signal.Amount > topBid.Amount {
-			topBid = signal
-		}
+		bid := signal
+		placingSignals++
+		workflow.Go(ctx, func(ictx workflow.Context) {
+			defer func() { placingSignals-- }()
+			receiveBid(ictx, bid)
+		})
 	})
 
+	// wait for a scheduled auction to open; bids sent as signals in the
+	// meantime stay buffered on the channel until the loop starts
+	if scheduled {
+		_, err = workflow.AwaitWithTimeout(ctx, s.StartTime.Sub(workflow.Now(ctx)), func() bool { return closed })
+		if err != nil {
+			return AuctionResult{}, err
+		}
+		scheduled = false
+		s.Version++
+		snapshot()
+	}
+
+	// drop the price on a schedule; uses a separate goroutine that sleeps
+	// between drops until the price hits the reserve or the auction closes
+	if r.Type == AuctionTypeDutch {
+		workflow.Go(ctx, func(ictx workflow.Context) {
+			for !closed && s.Price.Cmp(r.ReservePrice) > 0 {
+				if s.NextDropTime.IsZero() {
+					s.NextDropTime = workflow.Now(ictx).Add(r.PriceInterval)
+				}
+				workflow.AwaitWithTimeout(ictx, s.NextDropTime.Sub(workflow.Now(ictx)), func() bool { return closed })
+				if closed {
+					break
+				}
+				s.Price = maxMoney(s.Price.Sub(r.PriceStep), r.ReservePrice)
+				s.Version++
+				snapshot()
+				s.NextDropTime = time.Time{}
+			}
+			s.NextDropTime = time.Time{}
+		})
+	}
+
+	// notify displaced leaders; uses a separate goroutine so that slow or
+	// failing notification endpoints never block the auction
+	if r.Type == AuctionTypeEnglish {
+		workflow.Go(ctx, func(ictx workflow.Context) {
+			runOutbidNotifier(ictx, r, &s, &closed)
+		})
+	}
+
 	// receive auction over; uses a separate goroutine that will block until the
-	// auction is over before sending on the auctionOverChan.
+	// auction is over before sending on the auctionOverChan. The end time may
+	// move while we're waiting, so re-arm the timer whenever it changes.
 	auctionOverChan := workflow.NewChannel(ctx)
 	workflow.Go(ctx, func(ictx workflow.Context) {
-		wait := time.Until(time.Now().Add(r.Duration))
-		workflow.AwaitWithTimeout(ictx, wait, func() bool { return false })
+		for !closed {
+			deadline := s.EndTime
+			wait := deadline.Sub(workflow.Now(ictx))
+			if wait <= 0 {
+				break
+			}
+			workflow.AwaitWithTimeout(ictx, wait, func() bool { return closed || !s.EndTime.Equal(deadline) })
+		}
+		closed = true
+		s.Version++
 		auctionOverChan.Send(ictx, nil)
 	})
 	selector.AddReceive(auctionOverChan, func(c workflow.ReceiveChannel, more bool) {
@@ -76,12 +804,38 @@ func RunAuctionWF(ctx workflow.Context, r RunAuctionWFRequest) error {
 		doLoop = false
 	})
 
-	// loop receive bids until the auction is over
+	// loop receive bids until the auction is over; busy auctions continue as
+	// new to keep the history bounded, carrying their state (and the recent
+	// bid log) over to the next run
 	for doLoop {
 		selector.Select(ctx)
+		info := workflow.GetInfo(ctx)
+		if doLoop && !closed && (info.GetContinueAsNewSuggested() || info.GetCurrentHistoryLength() > maxHistoryLength) {
+			finishing = true
+			err = workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) && placingSignals == 0 })
+			if err != nil {
+				return AuctionResult{}, err
+			}
+			for bidChan.ReceiveAsync(&signal) {
+				receiveBid(ctx, signal)
+			}
+			// the commitments released along the way (including by the bids
+			// just drained) have to reach the bidders before this run ends
+			err = workflow.Await(ctx, func() bool { return workflow.AllHandlersFinished(ctx) && releasing == 0 })
+			if err != nil {
+				return AuctionResult{}, err
+			}
+			finishing = false
+			if !closed {
+				s.Bids = trimBidLog(r.Type, s.Bids)
+				r.State = &s
+				return AuctionResult{}, workflow.NewContinueAsNewError(ctx, RunAuctionWF, r)
+			}
+		}
 	}
+	finishing = true
 
-	// send the webhook with the results
+	// activities are retried until the webhook receives a 200
 	rp := temporal.RetryPolicy{
 		InitialInterval:    time.Second,
 		BackoffCoefficient: 5.0,
@@ -94,6 +848,99 @@ func RunAuctionWF(ctx workflow.Context, r RunAuctionWFRequest) error {
 		HeartbeatTimeout:    60 * time.Second,
 	}
 	ctx = workflow.WithActivityOptions(ctx, aopts)
-	err = workflow.ExecuteActivity(ctx, RunAuctionCompleteWebhook, r.Webhook, topBid).Get(ctx, nil)
-	return err
+
+	// release every remaining credit commitment once the auction is over;
+	// bids still being placed finish first (and are rejected, as the auction
+	// is closed)
+	releaseAll := func() {
+		if err := bidMu.Lock(ctx); err != nil {
+			return
+		}
+		defer bidMu.Unlock()
+		for _, bidder := range slices.Sorted(maps.Keys(s.Committed)) {
+			if err := releaseBid(ctx, s.Committed[bidder]).Get(ctx, nil); err != nil {
+				workflow.GetLogger(ctx).Warn("could not release commitment", "bidder", bidder, "error", err)
+			}
+		}
+		clear(s.Committed)
+	}
+
+	// a cancelled auction sends a cancellation instead of its results
+	if cancelled {
+		cancellation := AuctionCancellation{
+			Item:        r.Item,
+			Reason:      cancelReason,
+			BidCount:    s.BidCount,
+			CancelledAt: workflow.Now(ctx),
+		}
+		result := AuctionResult{
+			Item:         r.Item,
+			Outcome:      AuctionOutcomeCancelled,
+			ReservePrice: r.ReservePrice,
+			BidCount:     s.BidCount,
+			ClosedAt:     cancellation.CancelledAt,
+		}
+		releaseAll()
+		if r.CancelWebhook == "" {
+			workflow.GetLogger(ctx).Warn("auction cancelled without a cancel webhook", "item", r.Item)
+			return result, nil
+		}
+		err = workflow.ExecuteActivity(ctx, RunAuctionCancelledWebhook, r.CancelWebhook, cancellation).Get(ctx, nil)
+		return result, err
+	}
+
+	// tally up the outcome of the auction; sealed bids are opened now
+	topBid := s.TopBid
+	salePrice := topBid.Amount
+	var allocations []AuctionAllocation
+	switch {
+	case r.Type == AuctionTypeUniformPrice:
+		allocations, salePrice = allocateUniformPrice(r, s.SealedBids)
+		s.Price = salePrice
+		s.Version++
+		snapshot()
+	case IsSealed(r.Type):
+		topBid, salePrice, _ = settleSealedBids(r, s.SealedBids)
+		s.TopBid = topBid
+		s.Price = salePrice
+		s.Version++
+		snapshot()
+	}
+	result := AuctionResult{
+		Item:         r.Item,
+		ReservePrice: r.ReservePrice,
+		BidCount:     s.BidCount,
+		ClosedAt:     workflow.Now(ctx),
+		CloseReason:  closeReason,
+	}
+	switch {
+	case s.BidCount == 0:
+		result.Outcome = AuctionOutcomeNoBids
+	case r.Type == AuctionTypeUniformPrice && len(allocations) == 0:
+		result.Outcome = AuctionOutcomeReserveNotMet
+	case r.Type == AuctionTypeUniformPrice:
+		result.Outcome = AuctionOutcomeSold
+		result.Price = salePrice
+		result.Allocations = allocations
+	case topBid.Amount.Cmp(r.ReservePrice) < 0:
+		result.Outcome = AuctionOutcomeReserveNotMet
+	default:
+		result.Outcome = AuctionOutcomeSold
+		result.WinningBid = topBid
+		result.Price = salePrice
+	}
+
+	// collect payment before reporting the results
+	if result.Outcome == AuctionOutcomeSold {
+		result.Settlement = settleAuction(ctx, r, s, result)
+	}
+	releaseAll()
+
+	// send the webhook with the results; lots of a catalog report their
+	// results to the catalog instead
+	if r.Catalog != "" {
+		return result, nil
+	}
+	err = workflow.ExecuteActivity(ctx, RunAuctionCompleteWebhook, r.Webhook, result).Get(ctx, nil)
+	return result, err
 }
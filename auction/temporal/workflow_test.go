@@ -0,0 +1,918 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/converter"
+	"go.temporal.io/sdk/testsuite"
+	"go.temporal.io/sdk/workflow"
+)
+
+type AuctionWorkflowSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env   *testsuite.TestWorkflowEnvironment
+	start time.Time
+
+	// result is what the auction sent to its webhook
+	result  AuctionResult
+	updates int
+
+	// activities capture payment with payments
+	activities *Activities
+	payments   *FakePaymentProcessor
+}
+
+func TestAuctionWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(AuctionWorkflowSuite))
+}
+
+func (s *AuctionWorkflowSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.start = s.env.Now()
+	s.result = AuctionResult{}
+	s.updates = 0
+	s.payments = &FakePaymentProcessor{}
+	s.activities = &Activities{Payments: s.payments}
+	s.env.RegisterActivity(s.activities)
+	s.env.OnActivity(RunAuctionCompleteWebhook, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, endpoint string, result AuctionResult) error {
+			s.result = result
+			return nil
+		}).Maybe()
+}
+
+func (s *AuctionWorkflowSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+// updateOutcome is what became of an update sent with updateAt; it receives
+// the update's callbacks.
+type updateOutcome struct {
+	value  interface{}
+	result BidResult
+	err    error
+}
+
+func (o *updateOutcome) Accept() {}
+
+func (o *updateOutcome) Reject(err error) {
+	o.err = err
+}
+
+func (o *updateOutcome) Complete(result interface{}, err error) {
+	o.err = err
+	o.value = result
+	if r, ok := result.(BidResult); ok {
+		o.result = r
+	}
+}
+
+// updateAt sends an update once the delay has passed (in workflow time) and
+// records the outcome.
+func (s *AuctionWorkflowSuite) updateAt(delay time.Duration, name string, args ...interface{}) *updateOutcome {
+	outcome := &updateOutcome{}
+	s.updates++
+	id := fmt.Sprintf("%s-%d", name, s.updates)
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(name, id, outcome, args...)
+	}, delay)
+	return outcome
+}
+
+// bidAt places a bid through an update once the delay has passed.
+func (s *AuctionWorkflowSuite) bidAt(delay time.Duration, bid AuctionBid) *updateOutcome {
+	return s.updateAt(delay, UpdateTypeBid, bid)
+}
+
+// signalBidAt sends a bid as a signal once the delay has passed.
+func (s *AuctionWorkflowSuite) signalBidAt(delay time.Duration, bid AuctionBid) {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalTypeBid, bid)
+	}, delay)
+}
+
+// stateAt queries the auction state once the delay has passed.
+func (s *AuctionWorkflowSuite) stateAt(delay time.Duration) *QueryResultState {
+	state := &QueryResultState{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeState)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(state))
+	}, delay)
+	return state
+}
+
+// queryAt runs a query once the delay has passed and decodes its result
+// into out.
+func (s *AuctionWorkflowSuite) queryAt(delay time.Duration, out interface{}, queryType string, args ...interface{}) {
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(queryType, args...)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(out))
+	}, delay)
+}
+
+// run runs the auction to completion and returns the result it sent.
+func (s *AuctionWorkflowSuite) run(r RunAuctionWFRequest) AuctionResult {
+	s.env.ExecuteWorkflow(RunAuctionWF, r)
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	return s.result
+}
+
+func TestSoftCloseEndTime(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	r := RunAuctionWFRequest{
+		SoftCloseWindow:    5 * time.Minute,
+		SoftCloseExtension: 10 * time.Minute,
+		MaxDuration:        90 * time.Minute,
+	}
+	tests := []struct {
+		name string
+		r    RunAuctionWFRequest
+		end  time.Time
+		now  time.Time
+		want time.Time
+	}{
+		{name: "outside the window", r: r, end: end, now: start.Add(50 * time.Minute), want: end},
+		{name: "inside the window", r: r, end: end, now: start.Add(58 * time.Minute), want: start.Add(70 * time.Minute)},
+		{name: "at the start of the window", r: r, end: end, now: start.Add(55 * time.Minute), want: start.Add(70 * time.Minute)},
+		{name: "clamped to the hard cap", r: r, end: start.Add(85 * time.Minute), now: start.Add(82 * time.Minute), want: start.Add(90 * time.Minute)},
+		{name: "already at the hard cap", r: r, end: start.Add(90 * time.Minute), now: start.Add(89 * time.Minute), want: start.Add(90 * time.Minute)},
+		{name: "soft close off", r: RunAuctionWFRequest{}, end: end, now: start.Add(59 * time.Minute), want: end},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := softCloseEndTime(tt.r, start, tt.end, tt.now); !got.Equal(tt.want) {
+				t.Errorf("softCloseEndTime() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateAuctionRequest(t *testing.T) {
+	webhook := "http://localhost:8080/webhook"
+	tests := []struct {
+		name    string
+		r       RunAuctionWFRequest
+		wantErr bool
+	}{
+		{name: "no soft close", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour}},
+		{name: "soft close", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, SoftCloseWindow: time.Minute, SoftCloseExtension: time.Minute}},
+		{name: "hard cap", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, MaxDuration: 2 * time.Hour}},
+		{name: "hard cap before the end", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, MaxDuration: time.Minute}, wantErr: true},
+		{name: "negative window", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, SoftCloseWindow: -time.Minute}, wantErr: true},
+		{name: "no item", r: RunAuctionWFRequest{Webhook: webhook, Duration: time.Hour}, wantErr: true},
+		{name: "no webhook", r: RunAuctionWFRequest{Item: "vase", Duration: time.Hour}, wantErr: true},
+		{name: "catalog lot without a webhook", r: RunAuctionWFRequest{Item: "estate/vase", Catalog: "estate", Duration: time.Hour}},
+		{name: "no duration", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook}, wantErr: true},
+		{name: "unknown type", r: RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, Type: "silent"}, wantErr: true},
+		{
+			name: "dutch",
+			r:    RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, Type: AuctionTypeDutch, StartPrice: usd(100), PriceStep: usd(10), PriceInterval: time.Minute},
+		},
+		{
+			name:    "dutch without a price step",
+			r:       RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, Type: AuctionTypeDutch, StartPrice: usd(100), PriceInterval: time.Minute},
+			wantErr: true,
+		},
+		{
+			name:    "dutch starting below the reserve",
+			r:       RunAuctionWFRequest{Item: "vase", Webhook: webhook, Duration: time.Hour, Type: AuctionTypeDutch, ReservePrice: usd(200), StartPrice: usd(100), PriceStep: usd(10), PriceInterval: time.Minute},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.r.Validate()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func (s *AuctionWorkflowSuite) Test_SoftCloseExtendsTheAuction() {
+	s.bidAt(30*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.bidAt(58*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	extended := s.stateAt(59 * time.Minute)
+	s.bidAt(62*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(50)})
+	s.bidAt(67*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(60)})
+	s.bidAt(78*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(70)})
+	s.bidAt(86*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(80)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:               "vase",
+		Duration:           time.Hour,
+		Webhook:            "http://localhost:8080/webhook",
+		SoftCloseWindow:    5 * time.Minute,
+		SoftCloseExtension: 10 * time.Minute,
+		MaxDuration:        90 * time.Minute,
+	})
+
+	s.WithinDuration(s.start.Add(70*time.Minute), extended.EndTime, 0)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(usd(80), result.WinningBid.Amount)
+	s.WithinDuration(s.start.Add(90*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_InvalidRequestFails() {
+	s.env.ExecuteWorkflow(RunAuctionWF, RunAuctionWFRequest{
+		Item:        "vase",
+		Duration:    time.Hour,
+		MaxDuration: time.Minute,
+	})
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Error(s.env.GetWorkflowError())
+}
+
+func (s *AuctionWorkflowSuite) Test_Outcomes() {
+	tests := []struct {
+		name       string
+		bids       []AuctionBid
+		wantResult AuctionResult
+	}{
+		{
+			name: "sold",
+			bids: []AuctionBid{{Bidder: "alice", Amount: usd(20)}, {Bidder: "bob", Amount: usd(30)}},
+			wantResult: AuctionResult{
+				Outcome:    AuctionOutcomeSold,
+				WinningBid: AuctionBid{Bidder: "bob", Amount: usd(30)},
+				BidCount:   2,
+			},
+		},
+		{
+			name:       "reserve not met",
+			bids:       []AuctionBid{{Bidder: "alice", Amount: usd(20)}},
+			wantResult: AuctionResult{Outcome: AuctionOutcomeReserveNotMet, BidCount: 1},
+		},
+		{
+			name:       "no bids",
+			wantResult: AuctionResult{Outcome: AuctionOutcomeNoBids},
+		},
+	}
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			s.SetupTest()
+			for i, bid := range tt.bids {
+				s.bidAt(time.Duration(i+1)*time.Minute, bid)
+			}
+
+			result := s.run(RunAuctionWFRequest{
+				Item:         "vase",
+				Duration:     time.Hour,
+				ReservePrice: usd(25),
+				Webhook:      "http://localhost:8080/webhook",
+			})
+
+			s.Equal(tt.wantResult.Outcome, result.Outcome)
+			s.Equal(tt.wantResult.WinningBid.Bidder, result.WinningBid.Bidder)
+			s.Equal(tt.wantResult.WinningBid.Amount, result.WinningBid.Amount)
+			s.Equal(tt.wantResult.BidCount, result.BidCount)
+			s.Equal(usd(25), result.ReservePrice)
+			s.WithinDuration(s.start.Add(time.Hour), result.ClosedAt, 0)
+		})
+	}
+}
+
+func (s *AuctionWorkflowSuite) Test_BidsRejected() {
+	first := s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	noBidder := s.bidAt(2*time.Minute, AuctionBid{Amount: usd(50)})
+	zero := s.bidAt(3*time.Minute, AuctionBid{Bidder: "bob"})
+	lower := s.bidAt(4*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(25)})
+	belowIncrement := s.bidAt(5*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(30.5)})
+	raised := s.bidAt(6*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(31)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		MinIncrement: usd(1),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(first.err)
+	s.True(first.result.Accepted)
+	s.Error(noBidder.err)
+	s.Error(zero.err)
+	s.Error(lower.err)
+	s.Error(belowIncrement.err)
+	s.NoError(raised.err)
+	s.Equal("bob", raised.result.TopBid.Bidder)
+	s.WithinDuration(s.start.Add(time.Hour), raised.result.EndTime, 0)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(2, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_InvalidSignaledBidsDropped() {
+	s.signalBidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.signalBidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(20)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.Equal(1, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_ProxyBidding() {
+	alice := s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(10), MaxAmount: usd(100)})
+	bob := s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(50)})
+	bobAgain := s.bidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(60), MaxAmount: usd(120)})
+	hidden := s.stateAt(4 * time.Minute)
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		MinIncrement: usd(1),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(alice.err)
+	s.True(alice.result.Leading)
+	s.Equal(usd(25), alice.result.TopBid.Amount)
+	s.NoError(bob.err)
+	s.False(bob.result.Leading)
+	s.Equal(usd(51), bob.result.TopBid.Amount)
+	s.NoError(bobAgain.err)
+	s.True(bobAgain.result.Leading)
+	s.Equal(usd(101), hidden.Amount)
+	s.True(bobAgain.result.TopBid.MaxAmount.IsZero())
+
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(usd(101), result.WinningBid.Amount)
+	s.Equal(3, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_ProxyBidRejectedBelowIncrement() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	low := s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(30.50)})
+	repeat := s.bidAt(3*time.Minute, AuctionBid{Bidder: "alice", MaxAmount: usd(30)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		MinIncrement: usd(1),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Error(low.err)
+	s.Error(repeat.err)
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.Equal(usd(30), result.WinningBid.Amount)
+	s.Equal(1, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_DutchPriceAccepted() {
+	price := s.stateAt(15 * time.Minute)
+	low := s.bidAt(25*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(70)})
+	taker := s.bidAt(25*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(80)})
+	late := s.bidAt(25*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(80)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:          "vase",
+		Type:          AuctionTypeDutch,
+		Duration:      2 * time.Hour,
+		ReservePrice:  usd(50),
+		StartPrice:    usd(100),
+		PriceStep:     usd(10),
+		PriceInterval: 10 * time.Minute,
+		Webhook:       "http://localhost:8080/webhook",
+	})
+
+	s.Equal(usd(90), price.Price)
+	s.WithinDuration(s.start.Add(20*time.Minute), price.NextDropTime, 0)
+	s.Error(low.err)
+	s.NoError(taker.err)
+	s.Error(late.err)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(usd(80), result.WinningBid.Amount)
+	s.WithinDuration(s.start.Add(25*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_DutchPriceStopsAtReserve() {
+	state := s.stateAt(90 * time.Minute)
+
+	result := s.run(RunAuctionWFRequest{
+		Item:          "vase",
+		Type:          AuctionTypeDutch,
+		Duration:      2 * time.Hour,
+		ReservePrice:  usd(50),
+		StartPrice:    usd(100),
+		PriceStep:     usd(15),
+		PriceInterval: 10 * time.Minute,
+		Webhook:       "http://localhost:8080/webhook",
+	})
+
+	s.Equal(usd(50), state.Price)
+	s.True(state.NextDropTime.IsZero())
+	s.Equal(AuctionOutcomeNoBids, result.Outcome)
+	s.WithinDuration(s.start.Add(2*time.Hour), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_VickreyBidsStaySealed() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(50)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(80)})
+	revised := s.bidAt(3*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(70)})
+	state := s.stateAt(30 * time.Minute)
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Type:         AuctionTypeVickrey,
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(revised.err)
+	s.Empty(revised.result.TopBid.Bidder)
+	s.Empty(state.Bidder)
+	s.True(state.Amount.IsZero())
+	s.Equal(2, state.BidCount)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(usd(80), result.WinningBid.Amount)
+	s.Equal(usd(70), result.Price)
+	s.Equal(2, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_SealedFirstPriceReserveNotMet() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(20)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Type:         AuctionTypeSealedFirstPrice,
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Equal(AuctionOutcomeReserveNotMet, result.Outcome)
+	s.Empty(result.WinningBid.Bidder)
+}
+
+func (s *AuctionWorkflowSuite) Test_UniformPriceAllocations() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(20), Quantity: 3})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(15), Quantity: 4})
+	s.bidAt(3*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(12)})
+	tooMany := s.bidAt(4*time.Minute, AuctionBid{Bidder: "dave", Amount: usd(30), Quantity: 6})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "tickets",
+		Type:         AuctionTypeUniformPrice,
+		Quantity:     5,
+		Duration:     time.Hour,
+		ReservePrice: usd(10),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Error(tooMany.err)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal(usd(15), result.Price)
+	s.Equal([]AuctionAllocation{
+		{Bidder: "alice", Quantity: 3, Price: usd(15)},
+		{Bidder: "bob", Quantity: 2, Price: usd(15)},
+	}, result.Allocations)
+}
+
+func (s *AuctionWorkflowSuite) Test_BidLogAndLeaderboard() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.bidAt(3*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(35), MaxAmount: usd(60)})
+	s.bidAt(4*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(45), MaxAmount: usd(90)})
+	first, second := AuctionBidsPage{}, AuctionBidsPage{}
+	s.queryAt(5*time.Minute, &first, QueryTypeBids, 0, 3)
+	s.queryAt(5*time.Minute, &second, QueryTypeBids, 3, 3)
+	var standings []AuctionStanding
+	s.queryAt(5*time.Minute, &standings, QueryTypeLeaderboard)
+
+	s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		MinIncrement: usd(1),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Equal(4, first.Total)
+	s.Len(first.Bids, 3)
+	s.Equal(1, first.Bids[0].Seq)
+	s.Equal("carol", first.Bids[2].Bid.Bidder)
+	s.True(first.Bids[2].Bid.MaxAmount.IsZero())
+	s.Equal(3, second.Offset)
+	s.Len(second.Bids, 1)
+	s.Equal("alice", second.Bids[0].Bid.Bidder)
+	s.True(second.Bids[0].Bid.MaxAmount.IsZero())
+	s.Equal([]AuctionStanding{
+		{Rank: 1, Bidder: "alice", Amount: usd(61)},
+		{Rank: 2, Bidder: "carol", Amount: usd(60)},
+		{Rank: 3, Bidder: "bob", Amount: usd(40)},
+	}, standings)
+}
+
+func (s *AuctionWorkflowSuite) Test_BidLogCarriedOverContinueAsNew() {
+	r := RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	}
+	s.signalBidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SetContinueAsNewSuggested(true)
+	}, 2*time.Minute)
+	s.signalBidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.env.ExecuteWorkflow(RunAuctionWF, r)
+
+	var canErr *workflow.ContinueAsNewError
+	s.Require().True(errors.As(s.env.GetWorkflowError(), &canErr))
+	var next RunAuctionWFRequest
+	s.Require().NoError(converter.GetDefaultDataConverter().FromPayloads(canErr.Input, &next))
+	s.Require().NotNil(next.State)
+	s.Len(next.State.Bids, 2)
+	s.WithinDuration(s.start.Add(time.Hour), next.State.EndTime, 0)
+
+	// the next run picks up the log where the last one left off
+	s.SetupTest()
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(50)})
+	page := AuctionBidsPage{}
+	s.queryAt(2*time.Minute, &page, QueryTypeBids, 0, 10)
+	var standings []AuctionStanding
+	s.queryAt(2*time.Minute, &standings, QueryTypeLeaderboard)
+
+	result := s.run(next)
+
+	s.Equal(3, page.Total)
+	s.Equal([]int{1, 2, 3}, []int{page.Bids[0].Seq, page.Bids[1].Seq, page.Bids[2].Seq})
+	s.Equal("alice", standings[0].Bidder)
+	s.Equal(usd(50), standings[0].Amount)
+	s.Equal("bob", standings[1].Bidder)
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.Equal(3, result.BidCount)
+}
+
+func (s *AuctionWorkflowSuite) Test_ReleasesSentBeforeContinueAsNew() {
+	s.env.OnActivity(s.activities.CommitBid, mock.Anything, mock.Anything).Return(nil)
+	released := false
+	s.env.OnSignalExternalWorkflow(mock.Anything, BidderWorkflowID("alice"), "", SignalTypeRelease, mock.Anything).
+		Run(func(args mock.Arguments) {
+			time.Sleep(50 * time.Millisecond)
+			released = true
+		}).Return(nil).Once()
+	s.signalBidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SetContinueAsNewSuggested(true)
+	}, 2*time.Minute)
+	s.signalBidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.env.ExecuteWorkflow(RunAuctionWF, RunAuctionWFRequest{
+		Item:                "vase",
+		Duration:            time.Hour,
+		ReservePrice:        usd(25),
+		RequireRegistration: true,
+		Webhook:             "http://localhost:8080/webhook",
+	})
+
+	var canErr *workflow.ContinueAsNewError
+	s.Require().True(errors.As(s.env.GetWorkflowError(), &canErr))
+	s.True(released)
+	var next RunAuctionWFRequest
+	s.Require().NoError(converter.GetDefaultDataConverter().FromPayloads(canErr.Input, &next))
+	s.Require().NotNil(next.State)
+	s.NotContains(next.State.Committed, "alice")
+	s.Contains(next.State.Committed, "bob")
+}
+
+func (s *AuctionWorkflowSuite) Test_BuyNowClosesTheAuction() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	offered := s.stateAt(2 * time.Minute)
+	buyer := s.bidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(250)})
+	late := s.bidAt(3*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(300)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:                  "vase",
+		Duration:              time.Hour,
+		ReservePrice:          usd(100),
+		BuyNowPrice:           usd(200),
+		BuyNowDisableFraction: 0.5,
+		Webhook:               "http://localhost:8080/webhook",
+	})
+
+	s.Equal(usd(200), offered.BuyNowPrice)
+	s.NoError(buyer.err)
+	s.Error(late.err)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal(CloseReasonBuyNow, result.CloseReason)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(usd(200), result.WinningBid.Amount)
+	s.Equal(2, result.BidCount)
+	s.WithinDuration(s.start.Add(3*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_BuyNowWithdrawnAtFraction() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(40)})
+	stillOffered := s.stateAt(2 * time.Minute)
+	s.bidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(60)})
+	withdrawn := s.stateAt(4 * time.Minute)
+	s.bidAt(5*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(250)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:                  "vase",
+		Duration:              time.Hour,
+		ReservePrice:          usd(100),
+		BuyNowPrice:           usd(200),
+		BuyNowDisableFraction: 0.5,
+		Webhook:               "http://localhost:8080/webhook",
+	})
+
+	s.Equal(usd(200), stillOffered.BuyNowPrice)
+	s.True(withdrawn.BuyNowPrice.IsZero())
+	s.Equal(CloseReasonEndTime, result.CloseReason)
+	s.Equal("carol", result.WinningBid.Bidder)
+	s.Equal(usd(250), result.WinningBid.Amount)
+	s.WithinDuration(s.start.Add(time.Hour), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_CancelSendsCancellation() {
+	var cancellation AuctionCancellation
+	s.env.OnActivity(RunAuctionCancelledWebhook, mock.Anything, "http://localhost:8080/cancelled", mock.Anything).Return(
+		func(ctx context.Context, endpoint string, c AuctionCancellation) error {
+			cancellation = c
+			return nil
+		}).Once()
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	cancel := s.updateAt(10*time.Minute, UpdateTypeCancel, "damaged in transit")
+	late := s.bidAt(10*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+
+	s.run(RunAuctionWFRequest{
+		Item:          "vase",
+		Duration:      time.Hour,
+		ReservePrice:  usd(25),
+		Webhook:       "http://localhost:8080/webhook",
+		CancelWebhook: "http://localhost:8080/cancelled",
+	})
+
+	s.NoError(cancel.err)
+	s.Error(late.err)
+	s.Equal("vase", cancellation.Item)
+	s.Equal("damaged in transit", cancellation.Reason)
+	s.Equal(1, cancellation.BidCount)
+	s.WithinDuration(s.start.Add(10*time.Minute), cancellation.CancelledAt, 0)
+	s.env.AssertNotCalled(s.T(), "RunAuctionCompleteWebhook", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *AuctionWorkflowSuite) Test_ExtendPushesOutTheEnd() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	extend := s.updateAt(30*time.Minute, UpdateTypeExtend, 30*time.Minute)
+	negative := s.updateAt(31*time.Minute, UpdateTypeExtend, -time.Minute)
+	state := s.stateAt(70 * time.Minute)
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(extend.err)
+	s.Require().IsType(time.Time{}, extend.value)
+	s.WithinDuration(s.start.Add(90*time.Minute), extend.value.(time.Time), 0)
+	s.Error(negative.err)
+	s.False(state.Closed)
+	s.Equal(CloseReasonEndTime, result.CloseReason)
+	s.WithinDuration(s.start.Add(90*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_CloseNowSettlesEarly() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	closeNow := s.updateAt(10*time.Minute, UpdateTypeCloseNow)
+	extend := s.updateAt(10*time.Minute, UpdateTypeExtend, time.Hour)
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(closeNow.err)
+	s.Error(extend.err)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal(CloseReasonClosedEarly, result.CloseReason)
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.WithinDuration(s.start.Add(10*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_OutbidNotificationsCoalesced() {
+	sent := map[string][]OutbidNotification{}
+	s.env.OnActivity(RunAuctionOutbidWebhook, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, endpoint string, n OutbidNotification) error {
+			sent[endpoint] = append(sent[endpoint], n)
+			return nil
+		})
+	// a bidding war inside one debounce window: alice is displaced twice and
+	// carol once, but bob retakes the lead before his notification goes out
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.bidAt(time.Minute+10*time.Second, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	s.bidAt(time.Minute+20*time.Second, AuctionBid{Bidder: "alice", Amount: usd(50)})
+	s.bidAt(time.Minute+30*time.Second, AuctionBid{Bidder: "carol", Amount: usd(60), CallbackURL: "http://carol.example.com/outbid"})
+	s.bidAt(time.Minute+40*time.Second, AuctionBid{Bidder: "bob", Amount: usd(70)})
+
+	s.run(RunAuctionWFRequest{
+		Item:           "vase",
+		Duration:       time.Hour,
+		ReservePrice:   usd(25),
+		Webhook:        "http://localhost:8080/webhook",
+		NotifyWebhook:  "http://localhost:8080/outbid",
+		NotifyDebounce: time.Minute,
+	})
+
+	s.Require().Len(sent["http://localhost:8080/outbid"], 1)
+	alice := sent["http://localhost:8080/outbid"][0]
+	s.Equal("alice", alice.Bidder)
+	s.Equal(OutbidEventType, alice.Event)
+	s.Equal(usd(70), alice.TopAmount)
+	s.WithinDuration(s.start.Add(time.Minute+30*time.Second), alice.OutbidAt, 0)
+	s.Require().Len(sent["http://carol.example.com/outbid"], 1)
+	s.Equal("carol", sent["http://carol.example.com/outbid"][0].Bidder)
+}
+
+func (s *AuctionWorkflowSuite) Test_FailingNotificationDoesNotBlock() {
+	s.env.OnActivity(RunAuctionOutbidWebhook, mock.Anything, mock.Anything, mock.Anything).Return(
+		fmt.Errorf("connection refused"))
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	later := s.bidAt(10*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(50)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:           "vase",
+		Duration:       time.Hour,
+		ReservePrice:   usd(25),
+		Webhook:        "http://localhost:8080/webhook",
+		NotifyWebhook:  "http://localhost:8080/outbid",
+		NotifyDebounce: time.Minute,
+	})
+
+	s.NoError(later.err)
+	s.Equal(AuctionOutcomeSold, result.Outcome)
+	s.Equal("alice", result.WinningBid.Bidder)
+	s.WithinDuration(s.start.Add(time.Hour), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_ScheduledStart() {
+	scheduled := s.stateAt(10 * time.Minute)
+	early := s.bidAt(30*time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.signalBidAt(40*time.Minute, AuctionBid{Bidder: "carol", Amount: usd(35)})
+	open := s.bidAt(70*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		StartTime:    s.start.Add(time.Hour),
+		Duration:     30 * time.Minute,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Equal(AuctionStatusScheduled, scheduled.Status)
+	s.WithinDuration(s.start.Add(time.Hour), scheduled.StartTime, 0)
+	s.WithinDuration(s.start.Add(90*time.Minute), scheduled.EndTime, 0)
+	s.Error(early.err)
+	s.NoError(open.err)
+	s.WithinDuration(s.start.Add(90*time.Minute), open.result.EndTime, 0)
+	s.Equal("bob", result.WinningBid.Bidder)
+	s.Equal(2, result.BidCount)
+	s.WithinDuration(s.start.Add(90*time.Minute), result.ClosedAt, 0)
+}
+
+func (s *AuctionWorkflowSuite) Test_BidsInTheAuctionCurrency() {
+	yen := s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: Money{Units: 3000}})
+	fraction := s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: Money{Units: 3150}})
+	euros := s.bidAt(3*time.Minute, AuctionBid{Bidder: "bob", Amount: Money{Units: 4000, Currency: "EUR"}})
+	exact := s.bidAt(4*time.Minute, AuctionBid{Bidder: "bob", Amount: Money{Units: 40, Currency: "JPY"}})
+
+	result := s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Currency:     "JPY",
+		Duration:     time.Hour,
+		ReservePrice: Money{Units: 25, Currency: "JPY"},
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.NoError(yen.err)
+	s.Error(fraction.err, "31.50 can't be paid in yen")
+	s.Error(euros.err)
+	s.NoError(exact.err)
+	s.Equal(Money{Units: 40, Currency: "JPY"}, result.WinningBid.Amount)
+	s.Equal(2, result.BidCount)
+}
+
+// waitVersionAt sends a wait_version update for the version the auction is
+// at once the delay has passed; seen is set to that version.
+func (s *AuctionWorkflowSuite) waitVersionAt(delay time.Duration, seen *int) *updateOutcome {
+	outcome := &updateOutcome{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeVersion)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(seen))
+		s.env.UpdateWorkflow(UpdateTypeWaitVersion, "wait-"+delay.String(), outcome, *seen)
+	}, delay)
+	return outcome
+}
+
+func (s *AuctionWorkflowSuite) Test_WaitVersionReturnsOnChange() {
+	var seen int
+	wait := s.waitVersionAt(time.Minute, &seen)
+	var waiting bool
+	s.env.RegisterDelayedCallback(func() { waiting = wait.value == nil }, time.Minute+5*time.Second)
+	s.signalBidAt(time.Minute+10*time.Second, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	var changed interface{}
+	s.env.RegisterDelayedCallback(func() { changed = wait.value }, time.Minute+11*time.Second)
+
+	s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.True(waiting)
+	s.NoError(wait.err)
+	s.Require().IsType(0, changed)
+	s.Greater(changed.(int), seen)
+}
+
+func (s *AuctionWorkflowSuite) Test_WaitVersionTimesOut() {
+	var seen int
+	wait := s.waitVersionAt(time.Minute, &seen)
+	var waiting bool
+	s.env.RegisterDelayedCallback(func() { waiting = wait.value == nil }, time.Minute+VersionWaitTimeout-time.Second)
+	var unchanged interface{}
+	s.env.RegisterDelayedCallback(func() { unchanged = wait.value }, time.Minute+VersionWaitTimeout+time.Second)
+
+	s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.True(waiting)
+	s.NoError(wait.err)
+	s.Equal(seen, unchanged)
+}
+
+func (s *AuctionWorkflowSuite) Test_TimelineChartsTheTopBid() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	s.bidAt(2*time.Minute, AuctionBid{Bidder: "bob", Amount: usd(40)})
+	var timeline AuctionTimeline
+	s.queryAt(3*time.Minute, &timeline, QueryTypeTimeline)
+
+	s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Require().Len(timeline.Points, 3)
+	s.WithinDuration(s.start, timeline.Points[0].Time, 0)
+	s.Zero(timeline.Points[0].Value.BidCount)
+	s.WithinDuration(s.start.Add(time.Minute), timeline.Points[1].Time, 0)
+	s.Equal(AuctionSnapshot{Amount: usd(30), Bidder: "alice", BidCount: 1}, timeline.Points[1].Value)
+	s.WithinDuration(s.start.Add(2*time.Minute), timeline.Points[2].Time, 0)
+	s.Equal(AuctionSnapshot{Amount: usd(40), Bidder: "bob", BidCount: 2}, timeline.Points[2].Value)
+}
+
+func (s *AuctionWorkflowSuite) Test_SealedTimelineSecretUntilClose() {
+	s.bidAt(time.Minute, AuctionBid{Bidder: "alice", Amount: usd(30)})
+	var secret error
+	s.env.RegisterDelayedCallback(func() {
+		_, secret = s.env.QueryWorkflow(QueryTypeTimeline)
+	}, 2*time.Minute)
+
+	s.run(RunAuctionWFRequest{
+		Item:         "vase",
+		Type:         AuctionTypeSealedFirstPrice,
+		Duration:     time.Hour,
+		ReservePrice: usd(25),
+		Webhook:      "http://localhost:8080/webhook",
+	})
+
+	s.Error(secret)
+}
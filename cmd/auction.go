@@ -2,11 +2,16 @@ package main
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/brojonat/temporal-examples/auction/server"
@@ -39,11 +44,68 @@ func start_auction(ctx *cli.Context) error {
 		return err
 	}
 	body := temporal.RunAuctionWFRequest{
-		StartTime:    time.Now(),
-		Duration:     dur,
-		Item:         ctx.String("item"),
-		ReservePrice: ctx.Float64("reserve-price"),
-		Webhook:      ctx.String("webhook"),
+		StartTime:             time.Now(),
+		Duration:              dur,
+		Item:                  ctx.String("item"),
+		Currency:              ctx.String("currency"),
+		Webhook:               ctx.String("webhook"),
+		CancelWebhook:         ctx.String("cancel-webhook"),
+		NotifyWebhook:         ctx.String("notify-webhook"),
+		Type:                  ctx.String("type"),
+		Quantity:              ctx.Int("quantity"),
+		PricingRule:           ctx.String("pricing-rule"),
+		BuyNowDisableFraction: ctx.Float64("buy-now-disable-fraction"),
+		RequireRegistration:   ctx.Bool("require-registration"),
+	}
+	if body.ReservePrice, err = money_flag(ctx, "reserve-price"); err != nil {
+		return err
+	}
+	if body.MinIncrement, err = money_flag(ctx, "min-increment"); err != nil {
+		return err
+	}
+	if body.StartPrice, err = money_flag(ctx, "start-price"); err != nil {
+		return err
+	}
+	if body.PriceStep, err = money_flag(ctx, "price-step"); err != nil {
+		return err
+	}
+	if body.BuyNowPrice, err = money_flag(ctx, "buy-now-price"); err != nil {
+		return err
+	}
+	if ctx.IsSet("start-at") {
+		if body.StartTime, err = time.Parse(time.RFC3339, ctx.String("start-at")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("payment-deadline") {
+		if body.PaymentDeadline, err = time.ParseDuration(ctx.String("payment-deadline")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("notify-debounce") {
+		if body.NotifyDebounce, err = time.ParseDuration(ctx.String("notify-debounce")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("price-interval") {
+		if body.PriceInterval, err = time.ParseDuration(ctx.String("price-interval")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("soft-close-window") {
+		if body.SoftCloseWindow, err = time.ParseDuration(ctx.String("soft-close-window")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("soft-close-extension") {
+		if body.SoftCloseExtension, err = time.ParseDuration(ctx.String("soft-close-extension")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("max-duration") {
+		if body.MaxDuration, err = time.ParseDuration(ctx.String("max-duration")); err != nil {
+			return err
+		}
 	}
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -68,11 +130,35 @@ func start_auction(ctx *cli.Context) error {
 	return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
 }
 
+// money_flag parses an amount flag such as "12.50" or "12.50 EUR"; flags that
+// weren't set are zero.
+func money_flag(ctx *cli.Context, name string) (temporal.Money, error) {
+	if !ctx.IsSet(name) {
+		return temporal.Money{}, nil
+	}
+	m, err := temporal.ParseMoney(ctx.String(name))
+	if err != nil {
+		return m, fmt.Errorf("bad --%s: %w", name, err)
+	}
+	return m, nil
+}
+
 func auction_place_bid(ctx *cli.Context) error {
 	body := temporal.AuctionBid{
-		Item:   ctx.String("item"),
-		Bidder: ctx.String("bidder"),
-		Amount: ctx.Float64("amount"),
+		Item:        ctx.String("item"),
+		Bidder:      ctx.String("bidder"),
+		Quantity:    ctx.Int("quantity"),
+		CallbackURL: ctx.String("callback-url"),
+	}
+	var err error
+	if body.Amount, err = money_flag(ctx, "amount"); err != nil {
+		return err
+	}
+	if body.MaxAmount, err = money_flag(ctx, "max"); err != nil {
+		return err
+	}
+	if !body.Amount.IsPositive() && !body.MaxAmount.IsPositive() {
+		return fmt.Errorf("must supply a bid amount or a maximum bid")
 	}
 	b, err := json.Marshal(body)
 	if err != nil {
@@ -87,6 +173,266 @@ func auction_place_bid(ctx *cli.Context) error {
 		return err
 	}
 	defer res.Body.Close()
+	b, err = io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
+	}
+	switch res.StatusCode {
+	case http.StatusOK:
+		var result temporal.BidResult
+		if err = json.Unmarshal(b, &result); err != nil {
+			return fmt.Errorf("could not parse bid result: %w: %s", err, b)
+		}
+		if result.TopBid.Bidder == "" {
+			fmt.Printf("sealed bid accepted; auction ends at %s\n", result.EndTime.Format(time.RFC3339))
+			return nil
+		}
+		status := "bid accepted"
+		if !result.Leading {
+			status = "bid accepted but outbid by another bidder's maximum"
+		}
+		fmt.Printf(
+			"%s; top bid by %s for %s; auction ends at %s\n",
+			status, result.TopBid.Bidder, result.TopBid.Amount, result.EndTime.Format(time.RFC3339))
+		return nil
+	case http.StatusBadRequest:
+		var body convenience.DefaultJSONResponse
+		if err = json.Unmarshal(b, &body); err != nil {
+			return fmt.Errorf("could not parse message: %w: %s", err, b)
+		}
+		return fmt.Errorf("bid rejected: %s", body.Error)
+	default:
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+}
+
+func get_auction_state(ctx *cli.Context) error {
+	if ctx.Bool("follow") {
+		return follow_state(ctx, map[string]string{"item": ctx.String("item")})
+	}
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/get-state", nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	q.Add("item", ctx.String("item"))
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	var body convenience.DefaultJSONResponse
+	err = json.Unmarshal(b, &body)
+	if err != nil {
+		return fmt.Errorf("could not parse message: %w: %s", err, b)
+	}
+	fmt.Println(body.Message)
+	return nil
+}
+
+func get_auction_history(ctx *cli.Context) error {
+	path := "/bids"
+	if ctx.Bool("leaderboard") {
+		path = "/leaderboard"
+	}
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+path, nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	q.Add("item", ctx.String("item"))
+	q.Add("offset", strconv.Itoa(ctx.Int("offset")))
+	q.Add("limit", strconv.Itoa(ctx.Int("limit")))
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+
+	if ctx.Bool("leaderboard") {
+		var standings []temporal.AuctionStanding
+		if err = json.Unmarshal(b, &standings); err != nil {
+			return fmt.Errorf("could not parse leaderboard: %w: %s", err, b)
+		}
+		for _, s := range standings {
+			fmt.Printf("%d. %s: %s\n", s.Rank, s.Bidder, s.Amount)
+		}
+		return nil
+	}
+
+	var page temporal.AuctionBidsPage
+	if err = json.Unmarshal(b, &page); err != nil {
+		return fmt.Errorf("could not parse bids: %w: %s", err, b)
+	}
+	for _, rec := range page.Bids {
+		fmt.Printf("#%d %s %s bid %s\n", rec.Seq, rec.Time.Format(time.RFC3339), rec.Bid.Bidder, rec.Bid.Amount)
+	}
+	fmt.Printf("showing %d-%d of %d bids\n", page.Offset+1, page.Offset+len(page.Bids), page.Total)
+	if page.Dropped > 0 {
+		fmt.Printf("%d older bids are no longer kept\n", page.Dropped)
+	}
+	return nil
+}
+
+func auction_admin(ctx *cli.Context, path string, params map[string]string) error {
+	r, err := http.NewRequest(http.MethodPost, ctx.String("endpoint")+path, nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	if ctx.IsSet("item") {
+		q.Add("item", ctx.String("item"))
+	}
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
+	}
+	var body convenience.DefaultJSONResponse
+	if err = json.Unmarshal(b, &body); err != nil {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, body.Error)
+	}
+	fmt.Println(body.Message)
+	return nil
+}
+
+func auction_cancel(ctx *cli.Context) error {
+	return auction_admin(ctx, "/cancel", map[string]string{"reason": ctx.String("reason")})
+}
+
+func auction_extend(ctx *cli.Context) error {
+	if _, err := time.ParseDuration(ctx.String("by")); err != nil {
+		return err
+	}
+	return auction_admin(ctx, "/extend", map[string]string{"by": ctx.String("by")})
+}
+
+func auction_close(ctx *cli.Context) error {
+	return auction_admin(ctx, "/close", nil)
+}
+
+// read_catalog_lots reads the lots of a catalog from a JSON or CSV file. JSON
+// files hold a list of {"item", "reserve_price", "duration"} objects and CSV
+// files have an item,reserve_price[,duration] header; durations are optional
+// and use the Go time.Duration format.
+func read_catalog_lots(path string) ([]temporal.CatalogLot, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	type lotRow struct {
+		Item         string         `json:"item"`
+		ReservePrice temporal.Money `json:"reserve_price"`
+		Duration     string         `json:"duration"`
+	}
+	var rows []lotRow
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		records, err := csv.NewReader(f).ReadAll()
+		if err != nil {
+			return nil, err
+		}
+		if len(records) < 1 || len(records[0]) < 2 || records[0][0] != "item" || records[0][1] != "reserve_price" {
+			return nil, fmt.Errorf("csv must have an item,reserve_price[,duration] header")
+		}
+		for i, rec := range records[1:] {
+			row := lotRow{Item: rec[0]}
+			if row.ReservePrice, err = temporal.ParseMoney(rec[1]); err != nil {
+				return nil, fmt.Errorf("line %d: bad reserve price: %w", i+2, err)
+			}
+			if len(rec) > 2 {
+				row.Duration = rec[2]
+			}
+			rows = append(rows, row)
+		}
+	} else if err = json.NewDecoder(f).Decode(&rows); err != nil {
+		return nil, err
+	}
+
+	lots := make([]temporal.CatalogLot, len(rows))
+	for i, row := range rows {
+		lots[i] = temporal.CatalogLot{Item: row.Item, ReservePrice: row.ReservePrice}
+		if row.Duration != "" {
+			if lots[i].Duration, err = time.ParseDuration(row.Duration); err != nil {
+				return nil, fmt.Errorf("lot %q: %w", row.Item, err)
+			}
+		}
+	}
+	return lots, nil
+}
+
+func start_auction_catalog(ctx *cli.Context) error {
+	dur, err := time.ParseDuration(ctx.String("duration"))
+	if err != nil {
+		return err
+	}
+	lots, err := read_catalog_lots(ctx.String("file"))
+	if err != nil {
+		return fmt.Errorf("could not read lots: %w", err)
+	}
+	body := temporal.RunCatalogWFRequest{
+		Catalog:       ctx.String("catalog"),
+		Currency:      ctx.String("currency"),
+		StartTime:     time.Now(),
+		Duration:      dur,
+		Lots:          lots,
+		Webhook:       ctx.String("webhook"),
+		CancelWebhook: ctx.String("cancel-webhook"),
+		NotifyWebhook: ctx.String("notify-webhook"),
+
+		RequireRegistration: ctx.Bool("require-registration"),
+	}
+	if ctx.IsSet("start-at") {
+		if body.StartTime, err = time.Parse(time.RFC3339, ctx.String("start-at")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("stagger") {
+		if body.Stagger, err = time.ParseDuration(ctx.String("stagger")); err != nil {
+			return err
+		}
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodPost, ctx.String("endpoint")+"/catalog/start", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
 	if res.StatusCode == http.StatusOK {
 		return nil
 	}
@@ -97,13 +443,81 @@ func auction_place_bid(ctx *cli.Context) error {
 	return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
 }
 
-func get_auction_state(ctx *cli.Context) error {
-	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/get-state", nil)
+func get_auction_catalog_state(ctx *cli.Context) error {
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/catalog/get-state", nil)
 	if err != nil {
 		return err
 	}
 	q := r.URL.Query()
-	q.Add("item", ctx.String("item"))
+	q.Add("catalog", ctx.String("catalog"))
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	var body convenience.DefaultJSONResponse
+	err = json.Unmarshal(b, &body)
+	if err != nil {
+		return fmt.Errorf("could not parse message: %w: %s", err, b)
+	}
+	fmt.Println(body.Message)
+	return nil
+}
+
+func auction_register_bidder(ctx *cli.Context) error {
+	body := temporal.RunBidderWFRequest{Bidder: ctx.String("bidder")}
+	var err error
+	if body.CreditLimit, err = money_flag(ctx, "limit"); err != nil {
+		return err
+	}
+	b, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodPost, ctx.String("endpoint")+"/bidders/register", bytes.NewReader(b))
+	if err != nil {
+		return err
+	}
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	b, err = io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
+	}
+	return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+}
+
+func auction_set_bidder_limit(ctx *cli.Context) error {
+	if _, err := money_flag(ctx, "limit"); err != nil {
+		return err
+	}
+	return auction_admin(ctx, "/bidders/limit", map[string]string{
+		"bidder": ctx.String("bidder"),
+		"limit":  ctx.String("limit"),
+	})
+}
+
+func get_auction_bidder(ctx *cli.Context) error {
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/bidders/get-state", nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	q.Add("bidder", ctx.String("bidder"))
 	r.URL.RawQuery = q.Encode()
 	res, err := http.DefaultClient.Do(r)
 	if err != nil {
@@ -125,3 +539,29 @@ func get_auction_state(ctx *cli.Context) error {
 	fmt.Println(body.Message)
 	return nil
 }
+
+func get_auction_timeline(ctx *cli.Context) error {
+	b, err := fetch_timeline(ctx, map[string]string{"item": ctx.String("item")})
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("csv") {
+		fmt.Print(string(b))
+		return nil
+	}
+	var tl temporal.AuctionTimeline
+	if err = json.Unmarshal(b, &tl); err != nil {
+		return fmt.Errorf("could not parse timeline: %w: %s", err, b)
+	}
+	if len(tl.Points) == 0 {
+		fmt.Println("no timeline recorded yet")
+		return nil
+	}
+	amounts := make([]float64, len(tl.Points))
+	for i, p := range tl.Points {
+		amounts[i] = p.Value.Amount.Float()
+	}
+	last := tl.Points[len(tl.Points)-1].Value
+	fmt.Printf("%s %s (%d bids)\n", sparkline(amounts), last.Amount, last.BidCount)
+	return nil
+}
@@ -82,24 +82,106 @@ func main() {
 								Aliases:  []string{"i"},
 								Usage:    "Item to auction",
 							},
-							&cli.Float64Flag{
+							&cli.StringFlag{
 								Name:     "reserve-price",
 								Required: true,
 								Aliases:  []string{"reserve", "r"},
 								Usage:    "Reserve price of the auction",
 							},
+							&cli.StringFlag{
+								Name:  "currency",
+								Usage: "ISO 4217 currency of the auction",
+								Value: "USD",
+							},
+							&cli.BoolFlag{
+								Name:  "require-registration",
+								Usage: "Only accept bids from registered bidders whose credit limit covers the bid",
+							},
 							&cli.StringFlag{
 								Name:     "duration",
 								Required: true,
 								Aliases:  []string{"dur", "d"},
 								Usage:    "Auction duration in Go time.Duration format (e.g., 15m)",
 							},
+							&cli.StringFlag{
+								Name:  "start-at",
+								Usage: "Open the auction at this time in RFC3339 format (e.g., 2024-05-01T09:00:00Z); defaults to now",
+							},
+							&cli.StringFlag{
+								Name:  "type",
+								Usage: "Auction type (english, dutch, sealed_first_price, vickrey or uniform_price)",
+								Value: "english",
+							},
+							&cli.StringFlag{
+								Name:  "start-price",
+								Usage: "Starting price of a dutch auction",
+							},
+							&cli.StringFlag{
+								Name:  "price-step",
+								Usage: "Amount the price of a dutch auction drops by",
+							},
+							&cli.StringFlag{
+								Name:  "price-interval",
+								Usage: "How often the price of a dutch auction drops (e.g., 1m)",
+							},
+							&cli.IntFlag{
+								Name:  "quantity",
+								Usage: "Number of identical units sold in a uniform price auction",
+								Value: 1,
+							},
+							&cli.StringFlag{
+								Name:  "pricing-rule",
+								Usage: "Clearing price of a uniform price auction (lowest_winning or highest_losing)",
+								Value: "lowest_winning",
+							},
+							&cli.StringFlag{
+								Name:  "buy-now-price",
+								Usage: "Price at which a bidder can end an english auction immediately",
+							},
+							&cli.Float64Flag{
+								Name:  "buy-now-disable-fraction",
+								Usage: "Withdraw buy-it-now once bidding reaches this fraction of the reserve (0 withdraws it on the first bid)",
+							},
+							&cli.StringFlag{
+								Name:  "min-increment",
+								Usage: "Minimum amount by which a bid must beat the top bid",
+							},
+							&cli.StringFlag{
+								Name:  "soft-close-window",
+								Usage: "Bids that take the lead this close to the end extend the auction (e.g., 2m)",
+							},
+							&cli.StringFlag{
+								Name:  "soft-close-extension",
+								Usage: "How far a late top bid pushes out the end of the auction (e.g., 2m)",
+							},
+							&cli.StringFlag{
+								Name:  "max-duration",
+								Usage: "Hard cap on the total auction duration including extensions (e.g., 1h)",
+							},
 							&cli.StringFlag{
 								Name:    "webhook",
 								Aliases: []string{"web", "w"},
 								Usage:   "Webhook endpoint for auction results",
 								Value:   "http://localhost:8080/webhook",
 							},
+							&cli.StringFlag{
+								Name:  "cancel-webhook",
+								Usage: "Webhook endpoint for auction cancellations",
+								Value: "http://localhost:8080/webhook/cancel",
+							},
+							&cli.StringFlag{
+								Name:  "notify-webhook",
+								Usage: "Webhook endpoint for outbid notifications",
+								Value: "http://localhost:8080/webhook/outbid",
+							},
+							&cli.StringFlag{
+								Name:  "notify-debounce",
+								Usage: "Coalesce outbid notifications over this window (e.g., 30s)",
+							},
+							&cli.StringFlag{
+								Name:  "payment-deadline",
+								Usage: "How long each bidder has to pay before the item is offered to the next (e.g., 24h)",
+							},
 						},
 						Action: func(ctx *cli.Context) error {
 							return start_auction(ctx)
@@ -120,6 +202,11 @@ func main() {
 								Aliases:  []string{"i"},
 								Usage:    "Item to auction",
 							},
+							&cli.BoolFlag{
+								Name:    "follow",
+								Aliases: []string{"f"},
+								Usage:   "Keep printing the auction state as it changes until it closes",
+							},
 						},
 						Action: func(ctx *cli.Context) error {
 							return get_auction_state(ctx)
@@ -146,17 +233,328 @@ func main() {
 								Aliases:  []string{"b"},
 								Usage:    "Email for the bid",
 							},
-							&cli.Float64Flag{
-								Name:     "amount",
-								Required: true,
-								Aliases:  []string{"a"},
-								Usage:    "Amount to bid",
+							&cli.StringFlag{
+								Name:    "amount",
+								Aliases: []string{"a"},
+								Usage:   "Amount to bid, with an optional currency (e.g., 12.50 or 12.50 EUR)",
+							},
+							&cli.StringFlag{
+								Name:    "max",
+								Aliases: []string{"m"},
+								Usage:   "Secret maximum to bid automatically up to",
+							},
+							&cli.IntFlag{
+								Name:    "quantity",
+								Aliases: []string{"q"},
+								Usage:   "Number of units to bid for in a uniform price auction",
+								Value:   1,
+							},
+							&cli.StringFlag{
+								Name:  "callback-url",
+								Usage: "URL to notify if you are outbid",
 							},
 						},
 						Action: func(ctx *cli.Context) error {
 							return auction_place_bid(ctx)
 						},
 					},
+					{
+						Name:  "history",
+						Usage: "Show the bid log or leaderboard of an auction",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:     "item",
+								Required: true,
+								Aliases:  []string{"i"},
+								Usage:    "Item to auction",
+							},
+							&cli.IntFlag{
+								Name:  "offset",
+								Usage: "Number of bids to skip",
+							},
+							&cli.IntFlag{
+								Name:  "limit",
+								Usage: "Maximum number of bids to show",
+								Value: 50,
+							},
+							&cli.BoolFlag{
+								Name:    "leaderboard",
+								Aliases: []string{"l"},
+								Usage:   "Show the best bid of each bidder instead of the bid log",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return get_auction_history(ctx)
+						},
+					},
+					{
+						Name:  "timeline",
+						Usage: "Chart the top bid of an auction over time",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:     "item",
+								Required: true,
+								Aliases:  []string{"i"},
+								Usage:    "Item to auction",
+							},
+							&cli.BoolFlag{
+								Name:  "csv",
+								Usage: "Print the timeline as CSV instead of a sparkline",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return get_auction_timeline(ctx)
+						},
+					},
+					{
+						Name:  "cancel",
+						Usage: "Cancel an auction without selling the item",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:     "item",
+								Required: true,
+								Aliases:  []string{"i"},
+								Usage:    "Item to auction",
+							},
+							&cli.StringFlag{
+								Name:    "reason",
+								Aliases: []string{"r"},
+								Usage:   "Reason for cancelling the auction",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return auction_cancel(ctx)
+						},
+					},
+					{
+						Name:  "extend",
+						Usage: "Push out the end time of an auction",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:     "item",
+								Required: true,
+								Aliases:  []string{"i"},
+								Usage:    "Item to auction",
+							},
+							&cli.StringFlag{
+								Name:     "by",
+								Required: true,
+								Usage:    "Extension in Go time.Duration format (e.g., 15m)",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return auction_extend(ctx)
+						},
+					},
+					{
+						Name:  "close",
+						Usage: "Close an auction now and settle it",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:     "item",
+								Required: true,
+								Aliases:  []string{"i"},
+								Usage:    "Item to auction",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return auction_close(ctx)
+						},
+					},
+					{
+						Name:  "bidder",
+						Usage: "manage registered bidders and their credit limits",
+						Subcommands: []*cli.Command{
+							{
+								Name:  "register",
+								Usage: "register a bidder with a credit limit",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "endpoint",
+										Usage: "HTTP endpoint",
+										Value: "http://localhost:8080",
+									},
+									&cli.StringFlag{
+										Name:     "bidder",
+										Required: true,
+										Aliases:  []string{"b"},
+										Usage:    "Bidder identifier (e.g., email address)",
+									},
+									&cli.StringFlag{
+										Name:     "limit",
+										Required: true,
+										Aliases:  []string{"l"},
+										Usage:    "Credit limit, with an optional currency (e.g., 500 or 500 EUR)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									return auction_register_bidder(ctx)
+								},
+							},
+							{
+								Name:  "set-limit",
+								Usage: "adjust a registered bidder's credit limit",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "endpoint",
+										Usage: "HTTP endpoint",
+										Value: "http://localhost:8080",
+									},
+									&cli.StringFlag{
+										Name:     "bidder",
+										Required: true,
+										Aliases:  []string{"b"},
+										Usage:    "Bidder identifier (e.g., email address)",
+									},
+									&cli.StringFlag{
+										Name:     "limit",
+										Required: true,
+										Aliases:  []string{"l"},
+										Usage:    "Credit limit, with an optional currency (e.g., 500 or 500 EUR)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									return auction_set_bidder_limit(ctx)
+								},
+							},
+							{
+								Name:  "get-state",
+								Usage: "get a registered bidder's credit",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "endpoint",
+										Usage: "HTTP endpoint",
+										Value: "http://localhost:8080",
+									},
+									&cli.StringFlag{
+										Name:     "bidder",
+										Required: true,
+										Aliases:  []string{"b"},
+										Usage:    "Bidder identifier (e.g., email address)",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									return get_auction_bidder(ctx)
+								},
+							},
+						},
+					},
+					{
+						Name:  "catalog",
+						Usage: "run a catalog of lots as a set of auctions",
+						Subcommands: []*cli.Command{
+							{
+								Name:  "start",
+								Usage: "start a catalog",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "endpoint",
+										Usage: "HTTP server endpoint",
+										Value: "http://localhost:8080",
+									},
+									&cli.StringFlag{
+										Name:     "catalog",
+										Required: true,
+										Aliases:  []string{"c"},
+										Usage:    "Name of the catalog",
+									},
+									&cli.StringFlag{
+										Name:  "currency",
+										Usage: "ISO 4217 currency of the catalog",
+										Value: "USD",
+									},
+									&cli.BoolFlag{
+										Name:  "require-registration",
+										Usage: "Only accept bids from registered bidders whose credit limit covers the bid",
+									},
+									&cli.StringFlag{
+										Name:     "file",
+										Required: true,
+										Aliases:  []string{"f"},
+										Usage:    "JSON or CSV (item,reserve_price[,duration]) file listing the lots",
+									},
+									&cli.StringFlag{
+										Name:     "duration",
+										Required: true,
+										Aliases:  []string{"dur", "d"},
+										Usage:    "Duration of each lot in Go time.Duration format (e.g., 15m)",
+									},
+									&cli.StringFlag{
+										Name:  "stagger",
+										Usage: "Close each lot this long after the one before it (e.g., 1m)",
+									},
+									&cli.StringFlag{
+										Name:  "start-at",
+										Usage: "Open the catalog at this time in RFC3339 format (e.g., 2024-05-01T09:00:00Z); defaults to now",
+									},
+									&cli.StringFlag{
+										Name:    "webhook",
+										Aliases: []string{"web", "w"},
+										Usage:   "Webhook endpoint for the catalog report",
+										Value:   "http://localhost:8080/webhook/catalog",
+									},
+									&cli.StringFlag{
+										Name:  "cancel-webhook",
+										Usage: "Webhook endpoint for lot cancellations",
+										Value: "http://localhost:8080/webhook/cancel",
+									},
+									&cli.StringFlag{
+										Name:  "notify-webhook",
+										Usage: "Webhook endpoint for outbid notifications",
+										Value: "http://localhost:8080/webhook/outbid",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									return start_auction_catalog(ctx)
+								},
+							},
+							{
+								Name:  "get-state",
+								Usage: "get the running totals of a catalog",
+								Flags: []cli.Flag{
+									&cli.StringFlag{
+										Name:  "endpoint",
+										Usage: "HTTP endpoint",
+										Value: "http://localhost:8080",
+									},
+									&cli.StringFlag{
+										Name:     "catalog",
+										Required: true,
+										Aliases:  []string{"c"},
+										Usage:    "Name of the catalog",
+									},
+								},
+								Action: func(ctx *cli.Context) error {
+									return get_auction_catalog_state(ctx)
+								},
+							},
+						},
+					},
 				},
 			},
 			{
@@ -212,18 +610,48 @@ func main() {
 								Aliases:  []string{"p", "q"},
 								Usage:    "Prompt question for the poll",
 							},
+							&cli.StringFlag{
+								Name:  "id",
+								Usage: "ID to give the poll; generated by the server if not set",
+							},
 							&cli.StringSliceFlag{
 								Name:     "option",
 								Required: true,
 								Aliases:  []string{"opt", "o"},
 								Usage:    "Options for poll",
 							},
+							&cli.StringFlag{
+								Name:  "mode",
+								Usage: "Poll mode: plurality, ranked, approval, score, or quadratic",
+								Value: "plurality",
+							},
+							&cli.IntFlag{
+								Name:  "credits",
+								Usage: "Voice credit budget of each voter in a quadratic poll (default 100)",
+							},
+							&cli.StringFlag{
+								Name:  "visibility",
+								Usage: "Who can see the tallies before the poll closes: live, hidden_until_close, or participants_only",
+								Value: "live",
+							},
+							&cli.StringFlag{
+								Name:  "retention",
+								Usage: "How long the final results can be queried after the poll closes (default 24h)",
+							},
+							&cli.BoolFlag{
+								Name:  "schulze",
+								Usage: "Also find the Schulze (Condorcet) winner of a ranked poll",
+							},
 							&cli.StringFlag{
 								Name:     "duration",
 								Required: true,
 								Aliases:  []string{"dur", "d"},
 								Usage:    "Poll duration in Go time.Duration format (e.g., 15m)",
 							},
+							&cli.StringFlag{
+								Name:  "start-at",
+								Usage: "Open the poll at this time in RFC3339 format (e.g., 2024-05-01T09:00:00Z); defaults to now",
+							},
 							&cli.StringFlag{
 								Name:    "webhook",
 								Aliases: []string{"web", "w"},
@@ -245,10 +673,23 @@ func main() {
 								Value: "http://localhost:8080",
 							},
 							&cli.StringFlag{
-								Name:     "prompt",
-								Required: true,
-								Aliases:  []string{"p"},
-								Usage:    "Prompt of the poll to fetch results for",
+								Name:  "poll",
+								Usage: "ID of the poll to fetch results for",
+							},
+							&cli.StringFlag{
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:    "voter",
+								Aliases: []string{"v"},
+								Usage:   "ID of the voter asking, for polls that only show results to voters",
+							},
+							&cli.BoolFlag{
+								Name:    "follow",
+								Aliases: []string{"f"},
+								Usage:   "Keep printing the poll state as it changes until it closes",
 							},
 						},
 						Action: func(ctx *cli.Context) error {
@@ -265,29 +706,171 @@ func main() {
 								Value: "http://localhost:8080",
 							},
 							&cli.StringFlag{
-								Name:     "prompt",
-								Required: true,
-								Aliases:  []string{"p"},
-								Usage:    "Prompt of the poll to vote on",
+								Name:  "poll",
+								Usage: "ID of the poll to vote on",
 							},
 							&cli.StringFlag{
-								Name:     "option",
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:     "voter",
 								Required: true,
-								Aliases:  []string{"opt", "o"},
-								Usage:    "Option to cast vote for",
+								Aliases:  []string{"v"},
+								Usage:    "ID of the voter; voting again replaces the voter's earlier vote",
+							},
+							&cli.StringSliceFlag{
+								Name:    "option",
+								Aliases: []string{"opt", "o"},
+								Usage:   "Option to cast vote for; repeat to rank options in order of preference in ranked polls",
+							},
+							&cli.StringSliceFlag{
+								Name:  "approve",
+								Usage: "Option to approve of in approval polls; may be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "score",
+								Usage: "Score for an option in score polls as option=score (0-5); may be repeated",
+							},
+							&cli.StringSliceFlag{
+								Name:  "votes",
+								Usage: "Votes to cast for an option in quadratic polls as option=n (costing n² voice credits); may be repeated",
+							},
+							&cli.BoolFlag{
+								Name:  "dry-run",
+								Usage: "Only show what the ballot would cost in a quadratic poll, without casting it",
 							},
 							&cli.Float64Flag{
-								Name:     "amount",
-								Required: true,
-								Aliases:  []string{"a"},
-								Usage:    "Magnitude of vote",
-								Value:    1,
+								Name:    "amount",
+								Aliases: []string{"a"},
+								Usage:   "Magnitude of vote",
+								Value:   1,
 							},
 						},
 						Action: func(ctx *cli.Context) error {
 							return poll_vote(ctx)
 						},
 					},
+					{
+						Name:  "withdraw",
+						Usage: "withdraw a vote from a poll",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:  "poll",
+								Usage: "ID of the poll to withdraw from",
+							},
+							&cli.StringFlag{
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:     "voter",
+								Required: true,
+								Aliases:  []string{"v"},
+								Usage:    "ID of the voter withdrawing their vote",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return poll_withdraw(ctx)
+						},
+					},
+					{
+						Name:  "my-vote",
+						Usage: "show the vote a voter cast in a poll",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:  "poll",
+								Usage: "ID of the poll",
+							},
+							&cli.StringFlag{
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:     "voter",
+								Required: true,
+								Aliases:  []string{"v"},
+								Usage:    "ID of the voter",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return get_poll_my_vote(ctx)
+						},
+					},
+					{
+						Name:  "credits",
+						Usage: "show the voice credits a voter has left in a quadratic poll",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:  "poll",
+								Usage: "ID of the poll",
+							},
+							&cli.StringFlag{
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:     "voter",
+								Required: true,
+								Aliases:  []string{"v"},
+								Usage:    "ID of the voter",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return get_poll_credits(ctx)
+						},
+					},
+					{
+						Name:  "timeline",
+						Usage: "chart the tally of each option over time",
+						Flags: []cli.Flag{
+							&cli.StringFlag{
+								Name:  "endpoint",
+								Usage: "HTTP endpoint",
+								Value: "http://localhost:8080",
+							},
+							&cli.StringFlag{
+								Name:  "poll",
+								Usage: "ID of the poll",
+							},
+							&cli.StringFlag{
+								Name:    "prompt",
+								Aliases: []string{"p"},
+								Usage:   "Prompt of the poll to look up if no ID is given",
+							},
+							&cli.StringFlag{
+								Name:    "voter",
+								Aliases: []string{"v"},
+								Usage:   "ID of the voter, for polls that only show results to participants",
+							},
+							&cli.BoolFlag{
+								Name:  "csv",
+								Usage: "Print the timeline as CSV instead of sparklines",
+							},
+						},
+						Action: func(ctx *cli.Context) error {
+							return get_poll_timeline(ctx)
+						},
+					},
 				},
 			},
 			{
@@ -6,7 +6,13 @@ import (
 	"fmt"
 	"io"
 	"log/slog"
+	"maps"
+	"math"
 	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/brojonat/temporal-examples/convenience"
@@ -39,11 +45,27 @@ func start_poll(ctx *cli.Context) error {
 		return err
 	}
 	body := temporal.RunPollWFRequest{
+		ID:        ctx.String("id"),
 		StartTime: time.Now(),
 		Duration:  dur,
 		Prompt:    ctx.String("prompt"),
+		Mode:      ctx.String("mode"),
 		Options:   ctx.StringSlice("option"),
 		Webhook:   ctx.String("webhook"),
+		Schulze:   ctx.Bool("schulze"),
+		Credits:   ctx.Int("credits"),
+
+		Visibility: ctx.String("visibility"),
+	}
+	if ctx.IsSet("retention") {
+		if body.Retention, err = time.ParseDuration(ctx.String("retention")); err != nil {
+			return err
+		}
+	}
+	if ctx.IsSet("start-at") {
+		if body.StartTime, err = time.Parse(time.RFC3339, ctx.String("start-at")); err != nil {
+			return err
+		}
 	}
 	if len(body.Prompt) < 1 {
 		return fmt.Errorf("must supply a poll prompt")
@@ -64,31 +86,127 @@ func start_poll(ctx *cli.Context) error {
 		return err
 	}
 	defer res.Body.Close()
-	if res.StatusCode == http.StatusOK {
-		return nil
-	}
 	b, err = io.ReadAll(res.Body)
 	if err != nil {
 		return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
 	}
-	return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	var started struct {
+		ID string `json:"id"`
+	}
+	if err = json.Unmarshal(b, &started); err != nil {
+		return fmt.Errorf("could not parse response: %w: %s", err, b)
+	}
+	fmt.Printf("started poll %s\n", started.ID)
+	return nil
+}
+
+// poll_ref returns the URL parameters that pick out a poll: its ID, or else
+// the prompt to look it up by.
+func poll_ref(ctx *cli.Context) (map[string]string, error) {
+	switch {
+	case ctx.String("poll") != "":
+		return map[string]string{"poll": ctx.String("poll")}, nil
+	case ctx.String("prompt") != "":
+		return map[string]string{"prompt": ctx.String("prompt")}, nil
+	}
+	return nil, fmt.Errorf("must supply a poll ID or prompt")
 }
 
 func poll_vote(ctx *cli.Context) error {
 	body := temporal.PollVote{
-		Prompt: ctx.String("prompt"),
-		Option: ctx.String("option"),
-		Amount: ctx.Float64("amount"),
+		PollID:   ctx.String("poll"),
+		Prompt:   ctx.String("prompt"),
+		Voter:    ctx.String("voter"),
+		Amount:   ctx.Float64("amount"),
+		Approved: ctx.StringSlice("approve"),
 	}
-	if len(body.Prompt) < 1 {
-		return fmt.Errorf("must supply a poll prompt")
+
+	// several options are sent as a ranking, most preferred first
+	if opts := ctx.StringSlice("option"); len(opts) > 0 {
+		body.Option = opts[0]
+		if len(opts) > 1 {
+			body.Ranking = opts
+		}
 	}
-	if body.Option == "" {
+
+	var err error
+	if body.Scores, err = option_counts(ctx, "score"); err != nil {
+		return err
+	}
+	if body.Allocations, err = option_counts(ctx, "votes"); err != nil {
+		return err
+	}
+	if body.Option == "" && len(body.Approved) == 0 && len(body.Scores) == 0 && len(body.Allocations) == 0 {
 		return fmt.Errorf("must specify option")
 	}
 	if body.Amount < 0 {
 		return fmt.Errorf("cannot vote a negative amount")
 	}
+
+	// show what a quadratic ballot (--votes) costs before casting it; a dry
+	// run shows the cost of a plain vote too, which casts --amount votes for
+	// one option. Credits are only fetched for these, since only quadratic
+	// polls have them.
+	allocations := body.Allocations
+	if len(allocations) == 0 && ctx.Bool("dry-run") && body.Option != "" && body.Amount == math.Trunc(body.Amount) {
+		allocations = map[string]int{body.Option: int(body.Amount)}
+	}
+	if len(allocations) > 0 {
+		msg := fmt.Sprintf("this ballot costs %d voice credits", temporal.QuadraticCost(allocations))
+		if credits, err := fetch_poll_credits(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "could not fetch voice credits: %s\n", err)
+		} else {
+			msg += fmt.Sprintf(" of your %d (%d currently spent)", credits.Budget, credits.Spent)
+		}
+		fmt.Println(msg)
+	}
+	if ctx.Bool("dry-run") {
+		return nil
+	}
+	return send_poll_vote(ctx, body)
+}
+
+// option_counts parses the repeated option=n values of a flag.
+func option_counts(ctx *cli.Context, name string) (map[string]int, error) {
+	vals := ctx.StringSlice(name)
+	if len(vals) == 0 {
+		return nil, nil
+	}
+	counts := make(map[string]int, len(vals))
+	for _, s := range vals {
+		o, v, ok := strings.Cut(s, "=")
+		if !ok {
+			return nil, fmt.Errorf("bad %s %q; expected option=n", name, s)
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("bad %s %q: %w", name, s, err)
+		}
+		counts[o] = n
+	}
+	return counts, nil
+}
+
+func poll_withdraw(ctx *cli.Context) error {
+	return send_poll_vote(ctx, temporal.PollVote{
+		PollID:   ctx.String("poll"),
+		Prompt:   ctx.String("prompt"),
+		Voter:    ctx.String("voter"),
+		Withdraw: true,
+	})
+}
+
+// send_poll_vote casts (or withdraws) a voter's ballot
+func send_poll_vote(ctx *cli.Context, body temporal.PollVote) error {
+	if body.PollID == "" && body.Prompt == "" {
+		return fmt.Errorf("must supply a poll ID or prompt")
+	}
+	if body.Voter == "" {
+		return fmt.Errorf("must supply a voter")
+	}
 	b, err := json.Marshal(body)
 	if err != nil {
 		return err
@@ -109,16 +227,70 @@ func poll_vote(ctx *cli.Context) error {
 	if err != nil {
 		return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
 	}
+	var rejection temporal.VoteRejection
+	if err = json.Unmarshal(b, &rejection); err == nil && len(rejection.ValidOptions) > 0 {
+		return fmt.Errorf(
+			"vote rejected: %s (options are %s)",
+			rejection.Error, strings.Join(rejection.ValidOptions, ", "))
+	}
 	return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
 }
 
 func get_poll_state(ctx *cli.Context) error {
+	ref, err := poll_ref(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.String("voter") != "" {
+		ref["voter"] = ctx.String("voter")
+	}
+	if ctx.Bool("follow") {
+		return follow_state(ctx, ref)
+	}
 	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/get-state", nil)
 	if err != nil {
 		return err
 	}
 	q := r.URL.Query()
-	q.Add("prompt", ctx.String("prompt"))
+	for k, v := range ref {
+		q.Add(k, v)
+	}
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	var body convenience.DefaultJSONResponse
+	err = json.Unmarshal(b, &body)
+	if err != nil {
+		return fmt.Errorf("could not parse message: %w: %s", err, b)
+	}
+	fmt.Println(body.Message)
+	return nil
+}
+
+func get_poll_my_vote(ctx *cli.Context) error {
+	ref, err := poll_ref(ctx)
+	if err != nil {
+		return err
+	}
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/my-vote", nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	for k, v := range ref {
+		q.Add(k, v)
+	}
+	q.Add("voter", ctx.String("voter"))
 	r.URL.RawQuery = q.Encode()
 	res, err := http.DefaultClient.Do(r)
 	if err != nil {
@@ -140,3 +312,99 @@ func get_poll_state(ctx *cli.Context) error {
 	fmt.Println(body.Message)
 	return nil
 }
+
+// fetch_poll_credits fetches the voice credits a voter has left in a
+// quadratic poll.
+func fetch_poll_credits(ctx *cli.Context) (temporal.VoterCredits, error) {
+	var credits temporal.VoterCredits
+	ref, err := poll_ref(ctx)
+	if err != nil {
+		return credits, err
+	}
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/credits", nil)
+	if err != nil {
+		return credits, err
+	}
+	q := r.URL.Query()
+	for k, v := range ref {
+		q.Add(k, v)
+	}
+	q.Add("voter", ctx.String("voter"))
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return credits, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return credits, fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return credits, fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	if err = json.Unmarshal(b, &credits); err != nil {
+		return credits, fmt.Errorf("could not parse credits: %w: %s", err, b)
+	}
+	return credits, nil
+}
+
+func get_poll_credits(ctx *cli.Context) error {
+	credits, err := fetch_poll_credits(ctx)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(
+		"%s has spent %d of %d voice credits; %d remaining\n",
+		credits.Voter, credits.Spent, credits.Budget, credits.Remaining)
+	return nil
+}
+
+func get_poll_timeline(ctx *cli.Context) error {
+	ref, err := poll_ref(ctx)
+	if err != nil {
+		return err
+	}
+	if ctx.String("voter") != "" {
+		ref["voter"] = ctx.String("voter")
+	}
+	b, err := fetch_timeline(ctx, ref)
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("csv") {
+		fmt.Print(string(b))
+		return nil
+	}
+	var tl temporal.PollTimeline
+	if err = json.Unmarshal(b, &tl); err != nil {
+		return fmt.Errorf("could not parse timeline: %w: %s", err, b)
+	}
+	if len(tl.Points) == 0 {
+		fmt.Println("no timeline recorded yet")
+		return nil
+	}
+
+	// one line per option, ending with its latest tally
+	options := map[string]bool{}
+	for _, p := range tl.Points {
+		for o := range p.Value.Votes {
+			options[o] = true
+		}
+	}
+	keys := slices.Sorted(maps.Keys(options))
+	width := 0
+	for _, o := range keys {
+		width = max(width, len(o))
+	}
+	last := tl.Points[len(tl.Points)-1].Value
+	for _, o := range keys {
+		votes := make([]float64, len(tl.Points))
+		for i, p := range tl.Points {
+			votes[i] = p.Value.Votes[o]
+		}
+		fmt.Printf("%-*s %s %v\n", width, o, sparkline(votes), last.Votes[o])
+	}
+	fmt.Printf("%d voters\n", last.Voters)
+	return nil
+}
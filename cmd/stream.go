@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/brojonat/temporal-examples/convenience"
+	"github.com/urfave/cli/v2"
+)
+
+// follow_state renders each state event from a server's /stream endpoint as
+// it arrives, until the server ends the stream.
+func follow_state(ctx *cli.Context, params map[string]string) error {
+	r, err := http.NewRequestWithContext(ctx.Context, http.MethodGet, ctx.String("endpoint")+"/stream", nil)
+	if err != nil {
+		return err
+	}
+	q := r.URL.Query()
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	r.URL.RawQuery = q.Encode()
+	r.Header.Set("Accept", "text/event-stream")
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		b, err := io.ReadAll(res.Body)
+		if err != nil {
+			return fmt.Errorf("bad response code (%d) and error reading body: %w", res.StatusCode, err)
+		}
+		return fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	return convenience.ReadSSE(res.Body, func(event string, data []byte) error {
+		var body convenience.DefaultJSONResponse
+		if err := json.Unmarshal(data, &body); err != nil {
+			return fmt.Errorf("could not parse event: %w: %s", err, data)
+		}
+		if event == "error" {
+			return fmt.Errorf("stream failed: %s", body.Error)
+		}
+		fmt.Printf("[%s] %s\n", time.Now().Format(time.TimeOnly), strings.TrimRight(body.Message, "\n"))
+		return nil
+	})
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+)
+
+// fetch_timeline fetches a workflow's timeline from a server's /timeline
+// endpoint, as JSON or, with the csv flag, as CSV.
+func fetch_timeline(ctx *cli.Context, params map[string]string) ([]byte, error) {
+	r, err := http.NewRequest(http.MethodGet, ctx.String("endpoint")+"/timeline", nil)
+	if err != nil {
+		return nil, err
+	}
+	q := r.URL.Query()
+	for k, v := range params {
+		q.Add(k, v)
+	}
+	if ctx.Bool("csv") {
+		q.Add("format", "csv")
+	}
+	r.URL.RawQuery = q.Encode()
+	res, err := http.DefaultClient.Do(r)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading body: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("bad response code (%d): %s", res.StatusCode, b)
+	}
+	return b, nil
+}
+
+// sparkline renders the values as a row of bars scaled between their minimum
+// and maximum.
+func sparkline(values []float64) string {
+	bars := []rune("▁▂▃▄▅▆▇█")
+	if len(values) == 0 {
+		return ""
+	}
+	lo, hi := slices.Min(values), slices.Max(values)
+	var sb strings.Builder
+	for _, v := range values {
+		i := 0
+		if hi > lo {
+			i = int((v - lo) / (hi - lo) * float64(len(bars)-1))
+		}
+		sb.WriteRune(bars[i])
+	}
+	return sb.String()
+}
@@ -25,6 +25,18 @@ func WriteBadRequestError(w http.ResponseWriter, err error) {
 	json.NewEncoder(w).Encode(resp)
 }
 
+func WriteForbiddenError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusForbidden)
+	resp := DefaultJSONResponse{Error: err.Error()}
+	json.NewEncoder(w).Encode(resp)
+}
+
+func WriteNotFoundError(w http.ResponseWriter, err error) {
+	w.WriteHeader(http.StatusNotFound)
+	resp := DefaultJSONResponse{Error: err.Error()}
+	json.NewEncoder(w).Encode(resp)
+}
+
 func WriteEmptyResultError(w http.ResponseWriter) {
 	w.WriteHeader(http.StatusNotFound)
 	resp := DefaultJSONResponse{Error: "empty result set"}
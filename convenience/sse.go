@@ -0,0 +1,136 @@
+package convenience
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// StreamEvent is the payload of a state event: a human readable message along
+// with the state it describes.
+type StreamEvent struct {
+	Message string      `json:"message"`
+	State   interface{} `json:"state"`
+}
+
+// SSEWriter writes Server-Sent Events to an HTTP response.
+type SSEWriter struct {
+	w http.ResponseWriter
+	f http.Flusher
+}
+
+// NewSSEWriter writes the event stream headers and returns a writer for the
+// events that follow.
+func NewSSEWriter(w http.ResponseWriter) (*SSEWriter, error) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported by this response writer")
+	}
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	f.Flush()
+	return &SSEWriter{w: w, f: f}, nil
+}
+
+// WriteEvent sends an event with the JSON encoding of data as its payload.
+func (s *SSEWriter) WriteEvent(event string, data interface{}) error {
+	b, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	if _, err = fmt.Fprintf(s.w, "event: %s\ndata: %s\n\n", event, b); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+// WriteComment sends a comment, which clients ignore; it's used to keep idle
+// connections alive.
+func (s *SSEWriter) WriteComment(comment string) error {
+	if _, err := fmt.Fprintf(s.w, ": %s\n\n", comment); err != nil {
+		return err
+	}
+	s.f.Flush()
+	return nil
+}
+
+// StreamOnChange streams events to the client for as long as it's connected.
+// It checks the (cheap) version of the underlying state, then long-polls with
+// wait, which should block until the version moves past the one it's given
+// (or give up after a while) and return the current version. send, which
+// should write the new state to the stream, is only called when the version
+// has changed; a wait that gives up without a change sends a keep-alive
+// comment instead. Streaming stops once send reports that it's done.
+//
+// An error from the first version check is returned before anything is
+// written so the caller can report it as usual; later errors are sent to the
+// client as an "error" event.
+func StreamOnChange(
+	w http.ResponseWriter,
+	r *http.Request,
+	version func(context.Context) (int, error),
+	wait func(ctx context.Context, seen int) (int, error),
+	send func(context.Context, *SSEWriter) (bool, error),
+) error {
+	ctx := r.Context()
+	current, err := version(ctx)
+	if err != nil {
+		return err
+	}
+	sse, err := NewSSEWriter(w)
+	if err != nil {
+		return err
+	}
+	done, err := send(ctx, sse)
+	for err == nil && !done {
+		var v int
+		if v, err = wait(ctx, current); err != nil || v == current {
+			if err == nil {
+				err = sse.WriteComment("keep-alive")
+			}
+			continue
+		}
+		current = v
+		done, err = send(ctx, sse)
+	}
+	if err != nil && ctx.Err() == nil {
+		sse.WriteEvent("error", DefaultJSONResponse{Error: err.Error()})
+	}
+	return nil
+}
+
+// ReadSSE reads an event stream, calling fn with the name and payload of
+// each event until the stream ends or fn returns an error.
+func ReadSSE(r io.Reader, fn func(event string, data []byte) error) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	event, data := "", []byte{}
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		switch {
+		case len(line) == 0:
+			if len(data) > 0 {
+				if err := fn(event, data); err != nil {
+					return err
+				}
+			}
+			event, data = "", []byte{}
+		case bytes.HasPrefix(line, []byte(":")):
+		case bytes.HasPrefix(line, []byte("event:")):
+			event = string(bytes.TrimSpace(line[len("event:"):]))
+		case bytes.HasPrefix(line, []byte("data:")):
+			if len(data) > 0 {
+				data = append(data, '\n')
+			}
+			data = append(data, bytes.TrimPrefix(line[len("data:"):], []byte(" "))...)
+		}
+	}
+	return scanner.Err()
+}
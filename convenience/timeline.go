@@ -0,0 +1,69 @@
+package convenience
+
+import (
+	"time"
+)
+
+// Timeline is a bounded time series of workflow state, used to chart how a
+// workflow evolved. Time is split into intervals and each interval keeps only
+// its latest point. When the timeline grows past its limit, the interval is
+// doubled and the points are merged down to one per new interval, so a long
+// running workflow keeps an evenly downsampled history of at most Limit
+// points. Points are stamped with workflow time, so recording them is
+// deterministic.
+//
+// The zero value is ready to use: it starts at the first recorded point with
+// the default interval and limit.
+type Timeline[T any] struct {
+	Start    time.Time          `json:"start"`
+	Interval time.Duration      `json:"interval"`
+	Limit    int                `json:"limit"`
+	Points   []TimelinePoint[T] `json:"points"`
+}
+
+type TimelinePoint[T any] struct {
+	Time  time.Time `json:"time"`
+	Value T         `json:"value"`
+}
+
+const (
+	DefaultTimelineInterval = time.Second
+	DefaultTimelineLimit    = 120
+)
+
+// Record adds a point to the timeline, replacing the last point if it falls
+// in the same interval.
+func (tl *Timeline[T]) Record(t time.Time, v T) {
+	if tl.Start.IsZero() {
+		tl.Start = t
+	}
+	if tl.Interval <= 0 {
+		tl.Interval = DefaultTimelineInterval
+	}
+	if tl.Limit <= 0 {
+		tl.Limit = DefaultTimelineLimit
+	}
+	p := TimelinePoint[T]{Time: t, Value: v}
+	if n := len(tl.Points); n > 0 && tl.bucket(tl.Points[n-1].Time) == tl.bucket(t) {
+		tl.Points[n-1] = p
+		return
+	}
+	tl.Points = append(tl.Points, p)
+	for len(tl.Points) > tl.Limit {
+		tl.Interval *= 2
+		merged := tl.Points[:0]
+		for _, p := range tl.Points {
+			if n := len(merged); n > 0 && tl.bucket(merged[n-1].Time) == tl.bucket(p.Time) {
+				merged[n-1] = p
+				continue
+			}
+			merged = append(merged, p)
+		}
+		tl.Points = merged
+	}
+}
+
+// bucket returns the index of the interval t falls in.
+func (tl *Timeline[T]) bucket(t time.Time) int64 {
+	return int64(t.Sub(tl.Start) / tl.Interval)
+}
@@ -0,0 +1,88 @@
+package convenience
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimelineRecord(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	at := func(seconds ...int) []time.Time {
+		times := make([]time.Time, len(seconds))
+		for i, s := range seconds {
+			times[i] = start.Add(time.Duration(s) * time.Second)
+		}
+		return times
+	}
+	tests := []struct {
+		name         string
+		tl           Timeline[int]
+		times        []time.Time
+		wantInterval time.Duration
+		wantTimes    []time.Time
+	}{
+		{
+			name:         "zero value uses the defaults",
+			times:        at(0, 1, 2),
+			wantInterval: DefaultTimelineInterval,
+			wantTimes:    at(0, 1, 2),
+		},
+		{
+			name:         "same interval replaces the last point",
+			tl:           Timeline[int]{Interval: 10 * time.Second},
+			times:        at(0, 3, 9, 10, 15),
+			wantInterval: 10 * time.Second,
+			wantTimes:    at(9, 15),
+		},
+		{
+			name:         "interval doubles past the limit",
+			tl:           Timeline[int]{Interval: time.Second, Limit: 4},
+			times:        at(0, 1, 2, 3, 4),
+			wantInterval: 2 * time.Second,
+			wantTimes:    at(1, 3, 4),
+		},
+		{
+			name:         "interval keeps doubling until under the limit",
+			tl:           Timeline[int]{Interval: time.Second, Limit: 2},
+			times:        at(0, 1, 2, 3, 4, 5, 6, 7, 8),
+			wantInterval: 8 * time.Second,
+			wantTimes:    at(7, 8),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tl := tt.tl
+			for i, ts := range tt.times {
+				tl.Record(ts, i)
+			}
+			if !tl.Start.Equal(start) {
+				t.Errorf("start = %s, want %s", tl.Start, start)
+			}
+			if tl.Interval != tt.wantInterval {
+				t.Errorf("interval = %s, want %s", tl.Interval, tt.wantInterval)
+			}
+			if len(tl.Points) != len(tt.wantTimes) {
+				t.Fatalf("got %d points, want %d", len(tl.Points), len(tt.wantTimes))
+			}
+			for i, p := range tl.Points {
+				if !p.Time.Equal(tt.wantTimes[i]) {
+					t.Errorf("point %d at %s, want %s", i, p.Time, tt.wantTimes[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTimelineRecordStaysWithinLimit(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	var tl Timeline[int]
+	for i := range 10000 {
+		tl.Record(start.Add(time.Duration(i)*time.Second), i)
+		if len(tl.Points) > DefaultTimelineLimit {
+			t.Fatalf("%d points after %d records, want at most %d", len(tl.Points), i+1, DefaultTimelineLimit)
+		}
+	}
+	if last := tl.Points[len(tl.Points)-1]; last.Value != 9999 {
+		t.Errorf("last point = %d, want the latest value", last.Value)
+	}
+}
@@ -2,7 +2,10 @@ module github.com/brojonat/temporal-examples
 
 go 1.23.0
 
-require go.temporal.io/sdk v1.29.1
+require (
+	github.com/uber-go/tally/v4 v4.1.16
+	go.temporal.io/sdk v1.29.1
+)
 
 require (
 	github.com/beorn7/perks v1.0.1 // indirect
@@ -19,7 +22,6 @@ require (
 	github.com/prometheus/common v0.55.0 // indirect
 	github.com/prometheus/procfs v0.15.1 // indirect
 	github.com/twmb/murmur3 v1.1.8 // indirect
-	github.com/uber-go/tally/v4 v4.1.16 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 )
 
@@ -39,11 +41,11 @@ require (
 	github.com/robfig/cron v1.2.0 // indirect
 	github.com/russross/blackfriday/v2 v2.1.0 // indirect
 	github.com/stretchr/objx v0.5.2 // indirect
-	github.com/stretchr/testify v1.9.0 // indirect
+	github.com/stretchr/testify v1.9.0
 	github.com/uber-go/tally v3.5.10+incompatible
-	github.com/urfave/cli/v2 v2.27.4 // indirect
+	github.com/urfave/cli/v2 v2.27.4
 	github.com/xrash/smetrics v0.0.0-20240521201337-686a1a2994c1 // indirect
-	go.temporal.io/api v1.38.0 // indirect
+	go.temporal.io/api v1.38.0
 	go.temporal.io/sdk/contrib/tally v0.2.0
 	golang.org/x/exp v0.0.0-20231127185646-65229373498e // indirect
 	golang.org/x/net v0.28.0 // indirect
@@ -0,0 +1,124 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/brojonat/temporal-examples/poll/temporal"
+	enumspb "go.temporal.io/api/enums/v1"
+	"go.temporal.io/api/operatorservice/v1"
+	"go.temporal.io/api/serviceerror"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/client"
+	sdktemporal "go.temporal.io/sdk/temporal"
+)
+
+// Polls are addressed by ID. Callers may pick their own ID when starting a
+// poll, otherwise the server generates one. The prompt is kept in the
+// PollPrompt search attribute so that polls can still be found by their
+// prompt through a visibility search, and the PollStatus search attribute
+// tracks whether the poll has closed.
+
+// the search attributes holding a poll's prompt and status
+var (
+	promptKey = sdktemporal.NewSearchAttributeKeyKeyword(temporal.SearchAttributePrompt)
+	statusKey = sdktemporal.NewSearchAttributeKeyKeyword(temporal.SearchAttributeStatus)
+)
+
+// registerSearchAttributes adds the poll search attributes to the namespace
+// if they aren't there already. They're added one at a time so that one
+// that's already there doesn't keep the other from being added.
+func registerSearchAttributes(ctx context.Context, tc client.Client) error {
+	for _, name := range []string{temporal.SearchAttributePrompt, temporal.SearchAttributeStatus} {
+		_, err := tc.OperatorService().AddSearchAttributes(ctx, &operatorservice.AddSearchAttributesRequest{
+			Namespace: client.DefaultNamespace,
+			SearchAttributes: map[string]enumspb.IndexedValueType{
+				name: enumspb.INDEXED_VALUE_TYPE_KEYWORD,
+			},
+		})
+		var alreadyExists *serviceerror.AlreadyExists
+		if err != nil && !errors.As(err, &alreadyExists) {
+			return err
+		}
+	}
+	return nil
+}
+
+func idFromPoll(id string) string {
+	return fmt.Sprintf("poll: %s", id)
+}
+
+// newPollID returns a random poll ID.
+func newPollID() (string, error) {
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// pollWorkflowID returns the workflow ID of the poll a request is about. The
+// poll is given by its ID, or looked up by its prompt if there's no ID.
+func pollWorkflowID(ctx context.Context, tc client.Client, id, prompt string) (string, error) {
+	if id != "" {
+		return idFromPoll(id), nil
+	}
+	if prompt == "" {
+		return "", fmt.Errorf("must supply a poll ID or prompt")
+	}
+	return findPollByPrompt(ctx, tc, prompt)
+}
+
+// pollWorkflowIDFromQuery returns the workflow ID of the poll given by the
+// poll (ID) or prompt URL parameters.
+func pollWorkflowIDFromQuery(tc client.Client, r *http.Request) (string, error) {
+	return pollWorkflowID(r.Context(), tc, r.URL.Query().Get("poll"), r.URL.Query().Get("prompt"))
+}
+
+// findPollByPrompt searches the poll workflows for the one with the prompt.
+// Polls that haven't closed (by their PollStatus) are preferred over closed
+// ones, of which the most recent (listed first) is used. If several polls
+// that haven't closed share the prompt, the caller has to pick one by ID.
+func findPollByPrompt(ctx context.Context, tc client.Client, prompt string) (string, error) {
+	query := fmt.Sprintf(
+		"WorkflowType = 'RunPollWF' AND %s = %s",
+		temporal.SearchAttributePrompt, quoteQueryString(prompt))
+
+	// two open polls are enough to tell that the prompt is ambiguous
+	resp, err := tc.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		Query: fmt.Sprintf("%s AND %s != %s",
+			query, temporal.SearchAttributeStatus, quoteQueryString(temporal.PollStatusClosed)),
+		PageSize: 2,
+	})
+	if err != nil {
+		return "", err
+	}
+	switch open := resp.GetExecutions(); {
+	case len(open) == 1:
+		return open[0].GetExecution().GetWorkflowId(), nil
+	case len(open) > 1:
+		return "", fmt.Errorf("several polls ask %q; pick one by ID", prompt)
+	}
+
+	resp, err = tc.ListWorkflow(ctx, &workflowservice.ListWorkflowExecutionsRequest{
+		Query:    query,
+		PageSize: 1,
+	})
+	if err != nil {
+		return "", err
+	}
+	if closed := resp.GetExecutions(); len(closed) > 0 {
+		return closed[0].GetExecution().GetWorkflowId(), nil
+	}
+	return "", fmt.Errorf("no poll asks %q", prompt)
+}
+
+// quoteQueryString quotes s as a string literal in a visibility query
+func quoteQueryString(s string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(s) + "'"
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	commonpb "go.temporal.io/api/common/v1"
+	"go.temporal.io/api/workflow/v1"
+	"go.temporal.io/api/workflowservice/v1"
+	"go.temporal.io/sdk/mocks"
+)
+
+// listing returns a ListWorkflow response holding the workflows.
+func listing(ids ...string) *workflowservice.ListWorkflowExecutionsResponse {
+	resp := &workflowservice.ListWorkflowExecutionsResponse{}
+	for _, id := range ids {
+		resp.Executions = append(resp.Executions, &workflow.WorkflowExecutionInfo{
+			Execution: &commonpb.WorkflowExecution{WorkflowId: id},
+		})
+	}
+	return resp
+}
+
+// isOpenQuery matches the search for polls that haven't closed.
+func isOpenQuery(open bool) interface{} {
+	return mock.MatchedBy(func(r *workflowservice.ListWorkflowExecutionsRequest) bool {
+		return strings.Contains(r.Query, "PollStatus != 'closed'") == open
+	})
+}
+
+func TestPollWorkflowID(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		prompt  string
+		open    []string
+		closed  []string
+		want    string
+		wantErr string
+	}{
+		{name: "by ID", id: "abc", prompt: "favorite color?", want: "poll: abc"},
+		{name: "neither", wantErr: "must supply a poll ID or prompt"},
+		{name: "open poll", prompt: "favorite color?", open: []string{"poll: abc"}, want: "poll: abc"},
+		{name: "closed poll", prompt: "favorite color?", closed: []string{"poll: new", "poll: old"}, want: "poll: new"},
+		{name: "ambiguous prompt", prompt: "favorite color?", open: []string{"poll: abc", "poll: def"}, wantErr: "pick one by ID"},
+		{name: "unknown prompt", prompt: "favorite color?", wantErr: `no poll asks "favorite color?"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tc := &mocks.Client{}
+			tc.On("ListWorkflow", mock.Anything, isOpenQuery(true)).Return(listing(tt.open...), nil).Maybe()
+			tc.On("ListWorkflow", mock.Anything, isOpenQuery(false)).Return(listing(tt.closed...), nil).Maybe()
+
+			got, err := pollWorkflowID(context.Background(), tc, tt.id, tt.prompt)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("pollWorkflowID() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Fatalf("pollWorkflowID() = %q, %v, want %q", got, err, tt.want)
+			}
+			if tt.id != "" {
+				tc.AssertNotCalled(t, "ListWorkflow", mock.Anything, mock.Anything)
+			}
+		})
+	}
+}
+
+func TestQuoteQueryString(t *testing.T) {
+	got := quoteQueryString(`it's a \ test`)
+	if want := `'it\'s a \\ test'`; got != want {
+		t.Errorf("quoteQueryString() = %s, want %s", got, want)
+	}
+}
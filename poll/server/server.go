@@ -3,22 +3,26 @@ package server
 import (
 	"cmp"
 	"context"
+	"encoding/csv"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"net/http"
 	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/brojonat/temporal-examples/convenience"
 	"github.com/brojonat/temporal-examples/poll/temporal"
 	"github.com/brojonat/temporal-examples/worker"
+	"go.temporal.io/api/serviceerror"
 	"go.temporal.io/sdk/client"
+	sdktemporal "go.temporal.io/sdk/temporal"
 )
 
-func idFromPrompt(p string) string {
-	return fmt.Sprintf("poll: %s", p)
-}
-
 // run an http server with endpoints for the auction workflow
 func RunHTTPServer(
 	ctx context.Context,
@@ -36,10 +40,20 @@ func RunHTTPServer(
 	}
 	defer tc.Close()
 
+	// polls are started with their search attributes, which can only be set
+	// once they're registered
+	if err = registerSearchAttributes(ctx, tc); err != nil {
+		return fmt.Errorf("could not register the poll search attributes: %w", err)
+	}
+
 	mux := http.NewServeMux()
 	mux.Handle("POST /start", handleStart(l, tc))
 	mux.Handle("POST /vote", handleVote(l, tc))
 	mux.Handle("GET /get-state", handleGetState(l, tc))
+	mux.Handle("GET /stream", handleStream(l, tc))
+	mux.Handle("GET /my-vote", handleMyVote(l, tc))
+	mux.Handle("GET /credits", handleCredits(l, tc))
+	mux.Handle("GET /timeline", handleGetTimeline(l, tc))
 	mux.Handle("POST /webhook", handleResult(l, tc))
 
 	listenAddr := fmt.Sprintf(":%s", port)
@@ -57,25 +71,65 @@ func handleStart(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			convenience.WriteInternalError(l, w, err)
 			return
 		}
+		if payload.ID == "" {
+			if payload.ID, err = newPollID(); err != nil {
+				convenience.WriteInternalError(l, w, err)
+				return
+			}
+		}
+		if err = payload.Validate(); err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
 
+		// the prompt and status go in search attributes so the poll can be
+		// looked up by prompt; the workflow updates the status as it goes
+		status := temporal.PollStatusOpen
+		if payload.StartTime.After(time.Now()) {
+			status = temporal.PollStatusScheduled
+		}
 		wopts := client.StartWorkflowOptions{
-			ID:        idFromPrompt(payload.Prompt),
+			ID:        idFromPoll(payload.ID),
 			TaskQueue: worker.TaskQueue,
+
+			TypedSearchAttributes: sdktemporal.NewSearchAttributes(
+				promptKey.ValueSet(payload.Prompt),
+				statusKey.ValueSet(status),
+			),
+
+			WorkflowExecutionErrorWhenAlreadyStarted: true,
 		}
 		_, err = tc.ExecuteWorkflow(r.Context(), wopts, temporal.RunPollWF, payload)
 		if err != nil {
+			var alreadyStarted *serviceerror.WorkflowExecutionAlreadyStarted
+			if errors.As(err, &alreadyStarted) {
+				convenience.WriteBadRequestError(w, fmt.Errorf("poll %s already exists", payload.ID))
+				return
+			}
 			convenience.WriteInternalError(l, w, err)
 			return
 		}
-		convenience.WriteOK(w)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(startResponse{ID: payload.ID, Message: "ok"})
 	}
 }
 
+// startResponse tells the caller the ID of the poll they started
+type startResponse struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
 // query the workflow for the current top bid
 func handleGetState(l *slog.Logger, tc client.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		id := idFromPrompt(r.URL.Query().Get("prompt"))
-		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeState)
+		id, err := pollWorkflowIDFromQuery(tc, r)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		voter := r.URL.Query().Get("voter")
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeState, voter)
 		if err != nil {
 			convenience.WriteInternalError(l, w, err)
 			return
@@ -85,30 +139,146 @@ func handleGetState(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			convenience.WriteInternalError(l, w, err)
 			return
 		}
-		msg := fmt.Sprintf("Poll results for \"%s\":\n", result.Prompt)
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: pollMessage(result)})
+	}
+}
 
-		// to iterate over map in order of values, we have to unpack the
-		// map into a slice and sort by the votes
-		type optVotes struct {
-			Option string
-			Votes  float64
+// stream the state of the poll as it changes
+func handleStream(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pollWorkflowIDFromQuery(tc, r)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
 		}
-		ovs := []optVotes{}
-		for o, v := range result.Votes {
-			ovs = append(ovs, optVotes{Option: o, Votes: v})
+		version := func(ctx context.Context) (int, error) {
+			response, err := tc.QueryWorkflow(ctx, id, "", temporal.QueryTypeVersion)
+			if err != nil {
+				return 0, err
+			}
+			var v int
+			err = response.Get(&v)
+			return v, err
 		}
-		slices.SortFunc(ovs, func(a, b optVotes) int {
-			return cmp.Compare(b.Votes, a.Votes)
-		})
-		for _, ov := range ovs {
+		wait := func(ctx context.Context, seen int) (int, error) {
+			handle, err := tc.UpdateWorkflow(ctx, client.UpdateWorkflowOptions{
+				WorkflowID:   id,
+				UpdateName:   temporal.UpdateTypeWaitVersion,
+				Args:         []interface{}{seen},
+				WaitForStage: client.WorkflowUpdateStageCompleted,
+			})
+			if err != nil {
+				return 0, err
+			}
+			var v int
+			err = handle.Get(ctx, &v)
+			return v, err
+		}
+		voter := r.URL.Query().Get("voter")
+		send := func(ctx context.Context, sse *convenience.SSEWriter) (bool, error) {
+			response, err := tc.QueryWorkflow(ctx, id, "", temporal.QueryTypeState, voter)
+			if err != nil {
+				return false, err
+			}
+			var result temporal.PollResult
+			if err = response.Get(&result); err != nil {
+				return false, err
+			}
+			event := convenience.StreamEvent{Message: pollMessage(result), State: result}
+			return result.Status == temporal.PollStatusClosed, sse.WriteEvent("state", event)
+		}
+		err = convenience.StreamOnChange(w, r, version, wait, send)
+		if err != nil {
+			convenience.WriteInternalError(l, w, err)
+		}
+	}
+}
+
+// pollMessage describes the state of the poll
+func pollMessage(result temporal.PollResult) string {
+	if result.Status == temporal.PollStatusScheduled {
+		return fmt.Sprintf(
+			"Poll \"%s\" opens at %s and closes at %s\n",
+			result.Prompt, result.StartTime.Format(time.RFC3339), result.EndTime.Format(time.RFC3339))
+	}
+	msg := fmt.Sprintf("Poll results for \"%s\" (%d voters):\n", result.Prompt, result.Voters)
+	switch {
+	case result.Hidden && result.Visibility == temporal.PollVisibilityParticipantsOnly:
+		return msg + "\tresults are only shown to voters who have voted; pass your voter ID to see them\n"
+	case result.Hidden:
+		return msg + fmt.Sprintf("\tresults are hidden until the poll closes at %s\n", result.EndTime.Format(time.RFC3339))
+	}
+
+	// to iterate over map in order of values, we have to unpack the
+	// map into a slice and sort by the votes; score polls are sorted by
+	// their average score instead
+	type optVotes struct {
+		Option string
+		Votes  float64
+	}
+	ovs := []optVotes{}
+	for o, v := range result.Votes {
+		if result.Mode == temporal.PollModeScore {
+			v = result.Averages[o]
+		}
+		ovs = append(ovs, optVotes{Option: o, Votes: v})
+	}
+	slices.SortFunc(ovs, func(a, b optVotes) int {
+		return cmp.Compare(b.Votes, a.Votes)
+	})
+	for _, ov := range ovs {
+		switch result.Mode {
+		case temporal.PollModeApproval:
+			msg += fmt.Sprintf("\t%s: %v approvals\n", ov.Option, ov.Votes)
+		case temporal.PollModeScore:
+			msg += fmt.Sprintf("\t%s: %.2f average (%v points)\n", ov.Option, ov.Votes, result.Votes[ov.Option])
+		default:
 			msg += fmt.Sprintf("\t%s: %v\n", ov.Option, ov.Votes)
 		}
-		w.WriteHeader(http.StatusOK)
-		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
 	}
+	if result.Mode == temporal.PollModeRanked {
+		msg += runoffMessage(result)
+	}
+	return msg
 }
 
-// send a signal to the workflow with the supplied bid
+// runoffMessage describes the instant runoff of a ranked poll
+func runoffMessage(result temporal.PollResult) string {
+	msg := ""
+	for _, round := range result.Rounds {
+		counts := []string{}
+		for _, o := range result.Options {
+			if v, ok := round.Votes[o]; ok {
+				counts = append(counts, fmt.Sprintf("%s %d", o, v))
+			}
+		}
+		msg += fmt.Sprintf("Round %d: %s", round.Round, strings.Join(counts, ", "))
+		if round.Exhausted > 0 {
+			msg += fmt.Sprintf(" (%d exhausted)", round.Exhausted)
+		}
+		if len(round.Eliminated) > 0 {
+			msg += fmt.Sprintf("; eliminated %s", strings.Join(round.Eliminated, ", "))
+		}
+		msg += "\n"
+	}
+	msg += fmt.Sprintf("Runoff winner: %s\n", cmp.Or(result.Winner, "none (tied)"))
+	if len(result.Options) > 1 {
+		msg += "Pairwise preferences:\n"
+		for i, a := range result.Options {
+			for _, b := range result.Options[i+1:] {
+				msg += fmt.Sprintf("\t%s vs %s: %d-%d\n", a, b, result.Pairwise[a][b], result.Pairwise[b][a])
+			}
+		}
+	}
+	if result.SchulzeWinner != "" {
+		msg += fmt.Sprintf("Schulze winner: %s\n", result.SchulzeWinner)
+	}
+	return msg
+}
+
+// send an update to the workflow with the supplied vote; rejected votes are
+// reported along with the poll's options
 func handleVote(l *slog.Logger, tc client.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 
@@ -119,17 +289,190 @@ func handleVote(l *slog.Logger, tc client.Client) http.HandlerFunc {
 			return
 		}
 
-		id := idFromPrompt(payload.Prompt)
-		err = tc.SignalWorkflow(r.Context(), id, "", temporal.SignalTypeVote, payload)
+		id, err := pollWorkflowID(r.Context(), tc, payload.PollID, payload.Prompt)
 		if err != nil {
 			convenience.WriteBadRequestError(w, err)
 			return
 		}
+		handle, err := tc.UpdateWorkflow(r.Context(), client.UpdateWorkflowOptions{
+			WorkflowID:   id,
+			UpdateName:   temporal.UpdateTypeVote,
+			Args:         []interface{}{payload},
+			WaitForStage: client.WorkflowUpdateStageCompleted,
+		})
+		if err == nil {
+			err = handle.Get(r.Context(), nil)
+		}
+		if err != nil {
+			writeVoteError(l, w, err)
+			return
+		}
 
 		convenience.WriteOK(w)
 	}
 }
 
+// query the workflow for the ballot a voter cast
+func handleMyVote(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pollWorkflowIDFromQuery(tc, r)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		voter := r.URL.Query().Get("voter")
+		if voter == "" {
+			convenience.WriteBadRequestError(w, errors.New("must supply a voter"))
+			return
+		}
+		// the workflow only shows the ballot if the voter can see the results
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeMyVote, voter)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var ballot temporal.PollVote
+		if err = response.Get(&ballot); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		msg := fmt.Sprintf("%s voted %v for \"%s\"", ballot.Voter, ballot.Amount, ballot.Option)
+		switch {
+		case len(ballot.Ranking) > 0:
+			msg = fmt.Sprintf("%s ranked %s", ballot.Voter, strings.Join(ballot.Ranking, " > "))
+		case len(ballot.Approved) > 0:
+			msg = fmt.Sprintf("%s approved %s", ballot.Voter, strings.Join(ballot.Approved, ", "))
+		case len(ballot.Scores) > 0:
+			scores := []string{}
+			for _, o := range slices.Sorted(maps.Keys(ballot.Scores)) {
+				scores = append(scores, fmt.Sprintf("%s %d", o, ballot.Scores[o]))
+			}
+			msg = fmt.Sprintf("%s scored %s", ballot.Voter, strings.Join(scores, ", "))
+		case len(ballot.Allocations) > 0:
+			votes := []string{}
+			for _, o := range slices.Sorted(maps.Keys(ballot.Allocations)) {
+				votes = append(votes, fmt.Sprintf("%s %d", o, ballot.Allocations[o]))
+			}
+			msg = fmt.Sprintf(
+				"%s cast %s for %d voice credits",
+				ballot.Voter, strings.Join(votes, ", "), temporal.QuadraticCost(ballot.Allocations))
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(convenience.DefaultJSONResponse{Message: msg})
+	}
+}
+
+// query the workflow for the voice credits a voter has left
+func handleCredits(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pollWorkflowIDFromQuery(tc, r)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		voter := r.URL.Query().Get("voter")
+		if voter == "" {
+			convenience.WriteBadRequestError(w, errors.New("must supply a voter"))
+			return
+		}
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeCredits, voter)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var result temporal.VoterCredits
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(result)
+	}
+}
+
+// writeVoteError reports votes rejected by the workflow as a 400 listing the
+// poll's options, votes for a poll that doesn't exist as a 404, and anything
+// else as an internal error
+func writeVoteError(l *slog.Logger, w http.ResponseWriter, err error) {
+	var appErr *sdktemporal.ApplicationError
+	var notFound *serviceerror.NotFound
+	switch {
+	case errors.As(err, &appErr):
+		rejection := temporal.VoteRejection{Error: appErr.Message()}
+		if appErr.HasDetails() {
+			appErr.Details(&rejection)
+		}
+		w.WriteHeader(http.StatusBadRequest)
+		json.NewEncoder(w).Encode(rejection)
+	case errors.As(err, &notFound):
+		convenience.WriteNotFoundError(w, err)
+	default:
+		convenience.WriteInternalError(l, w, err)
+	}
+}
+
+// query the workflow for the timeline of the tallies, as JSON or as CSV with
+// format=csv; the CSV has a column for each option
+func handleGetTimeline(l *slog.Logger, tc client.Client) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := pollWorkflowIDFromQuery(tc, r)
+		if err != nil {
+			convenience.WriteBadRequestError(w, err)
+			return
+		}
+		voter := r.URL.Query().Get("voter")
+		response, err := tc.QueryWorkflow(r.Context(), id, "", temporal.QueryTypeTimeline, voter)
+		if err != nil {
+			writeQueryError(l, w, err)
+			return
+		}
+		var result temporal.PollTimeline
+		if err = response.Get(&result); err != nil {
+			convenience.WriteInternalError(l, w, err)
+			return
+		}
+		if r.URL.Query().Get("format") != "csv" {
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(result)
+			return
+		}
+		options := map[string]bool{}
+		for _, p := range result.Points {
+			for o := range p.Value.Votes {
+				options[o] = true
+			}
+		}
+		columns := slices.Sorted(maps.Keys(options))
+		w.Header().Set("Content-Type", "text/csv")
+		w.WriteHeader(http.StatusOK)
+		cw := csv.NewWriter(w)
+		cw.Write(append([]string{"time", "voters"}, columns...))
+		for _, p := range result.Points {
+			row := []string{p.Time.Format(time.RFC3339), strconv.Itoa(p.Value.Voters)}
+			for _, o := range columns {
+				row = append(row, strconv.FormatFloat(p.Value.Votes[o], 'f', -1, 64))
+			}
+			cw.Write(row)
+		}
+		cw.Flush()
+	}
+}
+
+// writeQueryError reports queries for results the caller can't see as
+// forbidden, other queries refused by the workflow (e.g., a voter without a
+// ballot) as bad requests, and anything else as an internal error
+func writeQueryError(l *slog.Logger, w http.ResponseWriter, err error) {
+	var queryFailed *serviceerror.QueryFailed
+	switch {
+	case errors.As(err, &queryFailed) && queryFailed.Message == temporal.ErrResultsHidden.Error():
+		convenience.WriteForbiddenError(w, temporal.ErrResultsHidden)
+	case errors.As(err, &queryFailed):
+		convenience.WriteBadRequestError(w, errors.New(queryFailed.Message))
+	default:
+		convenience.WriteInternalError(l, w, err)
+	}
+}
+
 // handle the winning bid webhook
 func handleResult(l *slog.Logger, tc client.Client) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -142,8 +485,10 @@ func handleResult(l *slog.Logger, tc client.Client) http.HandlerFunc {
 		}
 		l.Info(
 			"got poll result",
+			"id", payload.ID,
 			"prompt", payload.Prompt,
 			"votes", payload.Votes,
+			"voters", payload.Voters,
 		)
 		convenience.WriteOK(w)
 	}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brojonat/temporal-examples/convenience"
+	"github.com/brojonat/temporal-examples/poll/temporal"
+	"go.temporal.io/api/serviceerror"
+	sdktemporal "go.temporal.io/sdk/temporal"
+)
+
+func TestWriteVoteError(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	rejection := temporal.VoteRejection{Error: `"pink" is not an option of this poll`, ValidOptions: []string{"red", "blue"}}
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+		want     temporal.VoteRejection
+	}{
+		{
+			name:     "rejected by the workflow",
+			err:      sdktemporal.NewApplicationError(rejection.Error, temporal.ErrTypeInvalidVote, rejection),
+			wantCode: http.StatusBadRequest,
+			want:     rejection,
+		},
+		{
+			name:     "rejected without details",
+			err:      sdktemporal.NewApplicationError("poll is closed", ""),
+			wantCode: http.StatusBadRequest,
+			want:     temporal.VoteRejection{Error: "poll is closed"},
+		},
+		{
+			name:     "unknown poll",
+			err:      serviceerror.NewNotFound("workflow not found"),
+			wantCode: http.StatusNotFound,
+			want:     temporal.VoteRejection{Error: "workflow not found"},
+		},
+		{
+			name:     "request cancelled",
+			err:      context.Canceled,
+			wantCode: http.StatusInternalServerError,
+			want:     temporal.VoteRejection{Error: "internal error"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeVoteError(l, w, tt.err)
+			var resp temporal.VoteRejection
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantCode || resp.Error != tt.want.Error || len(resp.ValidOptions) != len(tt.want.ValidOptions) {
+				t.Errorf("got %d %+v, want %d %+v", w.Code, resp, tt.wantCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteQueryError(t *testing.T) {
+	l := slog.New(slog.NewTextHandler(io.Discard, nil))
+	tests := []struct {
+		name      string
+		err       error
+		wantCode  int
+		wantError string
+	}{
+		{name: "hidden results", err: serviceerror.NewQueryFailed(temporal.ErrResultsHidden.Error()), wantCode: http.StatusForbidden, wantError: temporal.ErrResultsHidden.Error()},
+		{name: "refused by the workflow", err: serviceerror.NewQueryFailed("bob has not voted in this poll"), wantCode: http.StatusBadRequest, wantError: "bob has not voted in this poll"},
+		{name: "request cancelled", err: context.Canceled, wantCode: http.StatusInternalServerError, wantError: "internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			writeQueryError(l, w, tt.err)
+			var resp convenience.DefaultJSONResponse
+			if err := json.NewDecoder(w.Body).Decode(&resp); err != nil {
+				t.Fatal(err)
+			}
+			if w.Code != tt.wantCode || resp.Error != tt.wantError {
+				t.Errorf("got %d %q, want %d %q", w.Code, resp.Error, tt.wantCode, tt.wantError)
+			}
+		})
+	}
+}
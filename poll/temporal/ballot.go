@@ -0,0 +1,156 @@
+package temporal
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+
+	"go.temporal.io/sdk/temporal"
+)
+
+// VoteRejection is the detail of an ErrTypeInvalidVote error. It lists the
+// poll's options so the voter can correct their vote.
+type VoteRejection struct {
+	Error        string   `json:"error"`
+	ValidOptions []string `json:"valid_options"`
+}
+
+// rejectVote wraps the reason a vote can't be cast in an ErrTypeInvalidVote
+// error.
+func rejectVote(r RunPollWFRequest, err error) error {
+	return temporal.NewApplicationError(
+		err.Error(), ErrTypeInvalidVote, VoteRejection{Error: err.Error(), ValidOptions: r.Options})
+}
+
+// checkBallot returns an error if the vote isn't a valid ballot for the poll.
+func checkBallot(r RunPollWFRequest, vote PollVote) error {
+	if vote.Voter == "" {
+		return fmt.Errorf("must supply a voter")
+	}
+	if vote.Withdraw {
+		return nil
+	}
+	if math.IsNaN(vote.Amount) || math.IsInf(vote.Amount, 0) {
+		return fmt.Errorf("amount must be a number, not %v", vote.Amount)
+	}
+	if vote.Amount < 0 {
+		return fmt.Errorf("cannot vote a negative amount")
+	}
+	if len(vote.Ranking) > 1 && r.Mode != PollModeRanked {
+		return fmt.Errorf("%s polls don't take rankings", r.Mode)
+	}
+	if len(vote.Approved) > 0 && r.Mode != PollModeApproval {
+		return fmt.Errorf("%s polls don't take approvals", r.Mode)
+	}
+	if len(vote.Scores) > 0 && r.Mode != PollModeScore {
+		return fmt.Errorf("%s polls don't take scores", r.Mode)
+	}
+	if len(vote.Allocations) > 0 && r.Mode != PollModeQuadratic {
+		return fmt.Errorf("%s polls don't take allocations", r.Mode)
+	}
+	switch r.Mode {
+	case PollModeRanked:
+		ranking := rankingOf(vote)
+		for i, o := range ranking {
+			if !slices.Contains(r.Options, o) {
+				return fmt.Errorf("%q is not an option of this poll", o)
+			}
+			if slices.Contains(ranking[:i], o) {
+				return fmt.Errorf("%q is ranked more than once", o)
+			}
+		}
+	case PollModeApproval:
+		if len(vote.Approved) == 0 {
+			return fmt.Errorf("must approve at least one option")
+		}
+		for i, o := range vote.Approved {
+			if !slices.Contains(r.Options, o) {
+				return fmt.Errorf("%q is not an option of this poll", o)
+			}
+			if slices.Contains(vote.Approved[:i], o) {
+				return fmt.Errorf("%q is approved more than once", o)
+			}
+		}
+	case PollModeScore:
+		if len(vote.Scores) == 0 {
+			return fmt.Errorf("must score at least one option")
+		}
+		for _, o := range slices.Sorted(maps.Keys(vote.Scores)) {
+			if !slices.Contains(r.Options, o) {
+				return fmt.Errorf("%q is not an option of this poll", o)
+			}
+			if score := vote.Scores[o]; score < 0 || score > MaxScore {
+				return fmt.Errorf("score for %q must be between 0 and %d, not %d", o, MaxScore, score)
+			}
+		}
+	case PollModeQuadratic:
+		return checkAllocations(r, vote)
+	default:
+		if !slices.Contains(r.Options, vote.Option) {
+			return fmt.Errorf("%q is not an option of this poll", vote.Option)
+		}
+	}
+	return nil
+}
+
+// recount recomputes the results from the latest ballot of every voter.
+// Voters are visited in a fixed order so the count comes out the same on
+// every replay.
+func recount(results *PollResult, r RunPollWFRequest, ballots map[string]PollVote) {
+	voters := slices.Sorted(maps.Keys(ballots))
+	results.Voters = len(voters)
+	results.Votes = make(map[string]float64, len(r.Options))
+	for _, o := range r.Options {
+		results.Votes[o] = 0.
+	}
+	switch r.Mode {
+	case PollModeRanked:
+		// the votes are the first preferences; the winner comes from the runoff
+		rankings := make([][]string, len(voters))
+		for i, voter := range voters {
+			rankings[i] = rankingOf(ballots[voter])
+			results.Votes[rankings[i][0]]++
+		}
+		results.Rounds, results.Winner = instantRunoff(r.Options, rankings)
+		results.Pairwise = pairwise(r.Options, rankings)
+		if r.Schulze {
+			results.SchulzeWinner = schulze(r.Options, results.Pairwise)
+		}
+	case PollModeApproval:
+		// the votes are the number of voters approving each option
+		for _, voter := range voters {
+			for _, o := range ballots[voter].Approved {
+				results.Votes[o]++
+			}
+		}
+	case PollModeScore:
+		// the votes are the total score of each option; options are averaged
+		// over the voters who scored them
+		ratings := map[string]int{}
+		for _, voter := range voters {
+			for o, score := range ballots[voter].Scores {
+				results.Votes[o] += float64(score)
+				ratings[o]++
+			}
+		}
+		results.Averages = make(map[string]float64, len(r.Options))
+		for _, o := range r.Options {
+			if ratings[o] > 0 {
+				results.Averages[o] = results.Votes[o] / float64(ratings[o])
+			}
+		}
+	case PollModeQuadratic:
+		// the votes are the number of votes (not credits) cast for each option
+		for _, voter := range voters {
+			for o, n := range allocationsOf(ballots[voter]) {
+				results.Votes[o] += float64(n)
+			}
+		}
+	default:
+		for _, voter := range voters {
+			ballot := ballots[voter]
+			results.Votes[ballot.Option] += ballot.Amount
+		}
+	}
+}
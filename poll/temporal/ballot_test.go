@@ -0,0 +1,65 @@
+package temporal
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCheckBallot(t *testing.T) {
+	options := []string{"red", "green", "blue"}
+	plurality := RunPollWFRequest{Options: options, Mode: PollModePlurality}
+	ranked := RunPollWFRequest{Options: options, Mode: PollModeRanked}
+	approval := RunPollWFRequest{Options: options, Mode: PollModeApproval}
+	score := RunPollWFRequest{Options: options, Mode: PollModeScore}
+	quadratic := RunPollWFRequest{Options: options, Mode: PollModeQuadratic, Credits: 10}
+	tests := []struct {
+		name    string
+		r       RunPollWFRequest
+		vote    PollVote
+		wantErr bool
+	}{
+		{name: "plurality", r: plurality, vote: PollVote{Voter: "ann", Option: "red", Amount: 1}},
+		{name: "missing voter", r: plurality, vote: PollVote{Option: "red", Amount: 1}, wantErr: true},
+		{name: "unknown option", r: plurality, vote: PollVote{Voter: "ann", Option: "pink", Amount: 1}, wantErr: true},
+		{name: "withdrawal", r: plurality, vote: PollVote{Voter: "ann", Withdraw: true}},
+		{name: "NaN amount", r: plurality, vote: PollVote{Voter: "ann", Option: "red", Amount: math.NaN()}, wantErr: true},
+		{name: "infinite amount", r: plurality, vote: PollVote{Voter: "ann", Option: "red", Amount: math.Inf(1)}, wantErr: true},
+		{name: "negative amount", r: plurality, vote: PollVote{Voter: "ann", Option: "red", Amount: -1}, wantErr: true},
+		{name: "ranking in a plurality poll", r: plurality, vote: PollVote{Voter: "ann", Ranking: []string{"red", "blue"}}, wantErr: true},
+		{name: "approvals in a ranked poll", r: ranked, vote: PollVote{Voter: "ann", Approved: []string{"red"}}, wantErr: true},
+		{name: "scores in an approval poll", r: approval, vote: PollVote{Voter: "ann", Scores: map[string]int{"red": 3}}, wantErr: true},
+		{name: "allocations in a score poll", r: score, vote: PollVote{Voter: "ann", Allocations: map[string]int{"red": 3}}, wantErr: true},
+
+		{name: "ranking", r: ranked, vote: PollVote{Voter: "ann", Ranking: []string{"blue", "red"}}},
+		{name: "plain vote in a ranked poll", r: ranked, vote: PollVote{Voter: "ann", Option: "blue"}},
+		{name: "ranking an unknown option", r: ranked, vote: PollVote{Voter: "ann", Ranking: []string{"blue", "pink"}}, wantErr: true},
+		{name: "ranking an option twice", r: ranked, vote: PollVote{Voter: "ann", Ranking: []string{"blue", "red", "blue"}}, wantErr: true},
+
+		{name: "approvals", r: approval, vote: PollVote{Voter: "ann", Approved: []string{"red", "green"}}},
+		{name: "no approvals", r: approval, vote: PollVote{Voter: "ann"}, wantErr: true},
+		{name: "approving an unknown option", r: approval, vote: PollVote{Voter: "ann", Approved: []string{"pink"}}, wantErr: true},
+		{name: "approving an option twice", r: approval, vote: PollVote{Voter: "ann", Approved: []string{"red", "red"}}, wantErr: true},
+
+		{name: "scores", r: score, vote: PollVote{Voter: "ann", Scores: map[string]int{"red": 0, "blue": MaxScore}}},
+		{name: "no scores", r: score, vote: PollVote{Voter: "ann"}, wantErr: true},
+		{name: "scoring an unknown option", r: score, vote: PollVote{Voter: "ann", Scores: map[string]int{"pink": 1}}, wantErr: true},
+		{name: "score too high", r: score, vote: PollVote{Voter: "ann", Scores: map[string]int{"red": MaxScore + 1}}, wantErr: true},
+		{name: "negative score", r: score, vote: PollVote{Voter: "ann", Scores: map[string]int{"red": -1}}, wantErr: true},
+
+		{name: "allocations within budget", r: quadratic, vote: PollVote{Voter: "ann", Allocations: map[string]int{"red": 2, "blue": 2}}},
+		{name: "allocations over budget", r: quadratic, vote: PollVote{Voter: "ann", Allocations: map[string]int{"red": 3, "blue": 2}}, wantErr: true},
+		{name: "negative allocation", r: quadratic, vote: PollVote{Voter: "ann", Allocations: map[string]int{"red": -1}}, wantErr: true},
+		{name: "allocating to an unknown option", r: quadratic, vote: PollVote{Voter: "ann", Allocations: map[string]int{"pink": 1}}, wantErr: true},
+		{name: "plain quadratic vote", r: quadratic, vote: PollVote{Voter: "ann", Option: "red", Amount: 3}},
+		{name: "plain quadratic vote over budget", r: quadratic, vote: PollVote{Voter: "ann", Option: "red", Amount: 4}, wantErr: true},
+		{name: "fractional quadratic vote", r: quadratic, vote: PollVote{Voter: "ann", Option: "red", Amount: 1.5}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkBallot(tt.r, tt.vote)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkBallot() = %v, want error: %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package temporal
+
+import (
+	"fmt"
+	"maps"
+	"math"
+	"slices"
+)
+
+// Quadratic polls give every voter a budget of voice credits. Casting n votes
+// for an option costs n² credits, so voters can express how strongly they
+// feel about an option, but piling votes onto one option gets expensive
+// quickly. A voter's ballot allocates votes across any of the options, and
+// its total cost must fit within the budget.
+
+const (
+	// query types
+	QueryTypeCredits = "credits"
+
+	// the voice credit budget of each voter when the poll doesn't set one
+	DefaultCredits = 100
+)
+
+// VoterCredits reports how much of a voter's budget their ballot spends.
+type VoterCredits struct {
+	Voter     string `json:"voter"`
+	Budget    int    `json:"budget"`
+	Spent     int    `json:"spent"`
+	Remaining int    `json:"remaining"`
+}
+
+// QuadraticCost returns the voice credits it costs to cast the votes.
+func QuadraticCost(allocations map[string]int) int {
+	cost := 0
+	for _, n := range allocations {
+		cost += n * n
+	}
+	return cost
+}
+
+// allocationsOf returns the votes the ballot casts for each option. A plain
+// vote for a single option casts Amount votes for it.
+func allocationsOf(ballot PollVote) map[string]int {
+	if len(ballot.Allocations) > 0 {
+		return ballot.Allocations
+	}
+	return map[string]int{ballot.Option: int(ballot.Amount)}
+}
+
+// checkAllocations returns an error if the ballot isn't a valid quadratic
+// ballot, or costs more than the voter's budget.
+func checkAllocations(r RunPollWFRequest, vote PollVote) error {
+	if len(vote.Allocations) == 0 {
+		if vote.Amount != math.Trunc(vote.Amount) {
+			return fmt.Errorf("must cast a whole number of votes, not %v", vote.Amount)
+		}
+		if vote.Amount > float64(r.Credits) {
+			return fmt.Errorf("cannot afford %v votes with a budget of %d", vote.Amount, r.Credits)
+		}
+	}
+	allocations := allocationsOf(vote)
+	for _, o := range slices.Sorted(maps.Keys(allocations)) {
+		if !slices.Contains(r.Options, o) {
+			return fmt.Errorf("%q is not an option of this poll", o)
+		}
+		if allocations[o] < 0 {
+			return fmt.Errorf("cannot cast a negative number of votes for %q", o)
+		}
+		if allocations[o] > r.Credits {
+			return fmt.Errorf("cannot afford %d votes for %q with a budget of %d", allocations[o], o, r.Credits)
+		}
+	}
+	if cost := QuadraticCost(allocations); cost > r.Credits {
+		return fmt.Errorf("ballot costs %d voice credits but the budget is %d", cost, r.Credits)
+	}
+	return nil
+}
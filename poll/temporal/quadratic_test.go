@@ -0,0 +1,20 @@
+package temporal
+
+import "testing"
+
+func TestQuadraticCost(t *testing.T) {
+	tests := []struct {
+		allocations map[string]int
+		want        int
+	}{
+		{allocations: nil, want: 0},
+		{allocations: map[string]int{"red": 1}, want: 1},
+		{allocations: map[string]int{"red": 3}, want: 9},
+		{allocations: map[string]int{"red": 2, "blue": 3}, want: 13},
+	}
+	for _, tt := range tests {
+		if got := QuadraticCost(tt.allocations); got != tt.want {
+			t.Errorf("QuadraticCost(%v) = %d, want %d", tt.allocations, got, tt.want)
+		}
+	}
+}
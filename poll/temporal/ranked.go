@@ -0,0 +1,166 @@
+package temporal
+
+import (
+	"slices"
+)
+
+// Ranked polls take an ordered ranking of the options from every voter. The
+// winner is found by instant runoff: each round, every ballot counts for its
+// highest ranked option still in the running, and the option(s) with the
+// fewest votes are eliminated until one option holds a majority. Polls can
+// also ask for the Schulze (Condorcet) winner, which is found from the
+// pairwise preferences of the voters instead.
+
+// RunoffRound is one round of an instant runoff count.
+type RunoffRound struct {
+	Round int `json:"round"`
+
+	// Votes counts the ballots for each option still in the running
+	Votes map[string]int `json:"votes"`
+
+	// Exhausted counts the ballots that rank none of the remaining options
+	Exhausted int `json:"exhausted"`
+
+	// Eliminated lists the options knocked out at the end of the round
+	Eliminated []string `json:"eliminated,omitempty"`
+}
+
+// rankingOf returns the voter's ranking of the options. A plain vote for a
+// single option counts as a ranking of just that option.
+func rankingOf(ballot PollVote) []string {
+	if len(ballot.Ranking) > 0 {
+		return ballot.Ranking
+	}
+	return []string{ballot.Option}
+}
+
+// instantRunoff counts the rankings round by round and returns the rounds
+// along with the winner. There's no winner if there are no ballots, or if the
+// last options standing are tied.
+func instantRunoff(options []string, rankings [][]string) ([]RunoffRound, string) {
+	running := slices.Clone(options)
+	rounds := []RunoffRound{}
+	for len(running) > 0 {
+		round := RunoffRound{Round: len(rounds) + 1, Votes: map[string]int{}}
+		for _, o := range running {
+			round.Votes[o] = 0
+		}
+		for _, ranking := range rankings {
+			i := slices.IndexFunc(ranking, func(o string) bool { return slices.Contains(running, o) })
+			if i < 0 {
+				round.Exhausted++
+				continue
+			}
+			round.Votes[ranking[i]]++
+		}
+
+		// the top option wins with a majority of the ballots still in play,
+		// or if it's the last one left
+		counted := len(rankings) - round.Exhausted
+		top, fewest := 0, len(rankings)+1
+		for _, o := range running {
+			top, fewest = max(top, round.Votes[o]), min(fewest, round.Votes[o])
+		}
+		if counted == 0 {
+			rounds = append(rounds, round)
+			return rounds, ""
+		}
+		if 2*top > counted || len(running) == 1 {
+			rounds = append(rounds, round)
+			for _, o := range running {
+				if round.Votes[o] == top {
+					return rounds, o
+				}
+			}
+		}
+
+		// otherwise knock out every option tied for the fewest votes; if
+		// that's all of them, the count ends in a tie
+		if fewest == top {
+			rounds = append(rounds, round)
+			return rounds, ""
+		}
+		for _, o := range running {
+			if round.Votes[o] == fewest {
+				round.Eliminated = append(round.Eliminated, o)
+			}
+		}
+		running = slices.DeleteFunc(running, func(o string) bool { return slices.Contains(round.Eliminated, o) })
+		rounds = append(rounds, round)
+	}
+	return rounds, ""
+}
+
+// pairwise counts, for every pair of options, how many voters rank the first
+// above the second. Ranked options are preferred over unranked ones, and
+// unranked options are tied with each other.
+func pairwise(options []string, rankings [][]string) map[string]map[string]int {
+	prefs := make(map[string]map[string]int, len(options))
+	for _, a := range options {
+		prefs[a] = make(map[string]int, len(options)-1)
+		for _, b := range options {
+			if a != b {
+				prefs[a][b] = 0
+			}
+		}
+	}
+	for _, ranking := range rankings {
+		for _, a := range options {
+			ra := slices.Index(ranking, a)
+			if ra < 0 {
+				continue
+			}
+			for _, b := range options {
+				if rb := slices.Index(ranking, b); a != b && (rb < 0 || ra < rb) {
+					prefs[a][b]++
+				}
+			}
+		}
+	}
+	return prefs
+}
+
+// schulze returns the Schulze winner of the pairwise preferences: the option
+// whose strongest path to every other option is at least as strong as the
+// path back. There's no winner if more than one option qualifies.
+func schulze(options []string, prefs map[string]map[string]int) string {
+	strength := make(map[string]map[string]int, len(options))
+	for _, a := range options {
+		strength[a] = make(map[string]int, len(options))
+		for _, b := range options {
+			if a != b && prefs[a][b] > prefs[b][a] {
+				strength[a][b] = prefs[a][b]
+			}
+		}
+	}
+	for _, i := range options {
+		for _, j := range options {
+			if i == j {
+				continue
+			}
+			for _, k := range options {
+				if k == i || k == j {
+					continue
+				}
+				strength[j][k] = max(strength[j][k], min(strength[j][i], strength[i][k]))
+			}
+		}
+	}
+	winners := []string{}
+	for _, a := range options {
+		beatsAll := true
+		for _, b := range options {
+			if a != b && strength[a][b] < strength[b][a] {
+				beatsAll = false
+				break
+			}
+		}
+		if beatsAll {
+			winners = append(winners, a)
+		}
+	}
+	if len(winners) != 1 {
+		return ""
+	}
+	return winners[0]
+}
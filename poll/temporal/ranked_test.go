@@ -0,0 +1,147 @@
+package temporal
+
+import (
+	"slices"
+	"testing"
+)
+
+// repeat returns n copies of the ranking.
+func repeat(n int, ranking ...string) [][]string {
+	rankings := make([][]string, n)
+	for i := range rankings {
+		rankings[i] = ranking
+	}
+	return rankings
+}
+
+// tennessee is the classic example of instant runoff and Condorcet methods
+// disagreeing: Knoxville wins the runoff but Nashville beats every other city
+// head to head.
+var tennessee = slices.Concat(
+	repeat(42, "memphis", "nashville", "chattanooga", "knoxville"),
+	repeat(26, "nashville", "chattanooga", "knoxville", "memphis"),
+	repeat(15, "chattanooga", "knoxville", "nashville", "memphis"),
+	repeat(17, "knoxville", "chattanooga", "nashville", "memphis"),
+)
+
+var cities = []string{"memphis", "nashville", "chattanooga", "knoxville"}
+
+func TestInstantRunoff(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []string
+		rankings   [][]string
+		wantWinner string
+		wantRounds int
+	}{
+		{name: "tennessee", options: cities, rankings: tennessee, wantWinner: "knoxville", wantRounds: 3},
+		{
+			name:       "first round majority",
+			options:    []string{"a", "b", "c"},
+			rankings:   slices.Concat(repeat(3, "a", "b"), repeat(1, "b"), repeat(1, "c")),
+			wantWinner: "a",
+			wantRounds: 1,
+		},
+		{
+			name:       "exhausted ballots don't count toward the majority",
+			options:    []string{"a", "b", "c"},
+			rankings:   slices.Concat(repeat(3, "a"), repeat(2, "b"), repeat(2, "c")),
+			wantWinner: "a",
+			wantRounds: 2,
+		},
+		{
+			name:       "tie between the last options",
+			options:    []string{"a", "b"},
+			rankings:   slices.Concat(repeat(2, "a"), repeat(2, "b")),
+			wantWinner: "",
+			wantRounds: 1,
+		},
+		{name: "no ballots", options: []string{"a", "b"}, wantWinner: "", wantRounds: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rounds, winner := instantRunoff(tt.options, tt.rankings)
+			if winner != tt.wantWinner || len(rounds) != tt.wantRounds {
+				t.Errorf("got %q after %d rounds, want %q after %d rounds", winner, len(rounds), tt.wantWinner, tt.wantRounds)
+			}
+		})
+	}
+}
+
+func TestInstantRunoffRounds(t *testing.T) {
+	rounds, _ := instantRunoff(cities, tennessee)
+	want := []RunoffRound{
+		{Round: 1, Votes: map[string]int{"memphis": 42, "nashville": 26, "chattanooga": 15, "knoxville": 17}, Eliminated: []string{"chattanooga"}},
+		{Round: 2, Votes: map[string]int{"memphis": 42, "nashville": 26, "knoxville": 32}, Eliminated: []string{"nashville"}},
+		{Round: 3, Votes: map[string]int{"memphis": 42, "knoxville": 58}},
+	}
+	if len(rounds) != len(want) {
+		t.Fatalf("got %d rounds, want %d", len(rounds), len(want))
+	}
+	for i := range want {
+		got := rounds[i]
+		if got.Round != want[i].Round || !slices.Equal(got.Eliminated, want[i].Eliminated) || len(got.Votes) != len(want[i].Votes) {
+			t.Errorf("round %d = %+v, want %+v", i+1, got, want[i])
+			continue
+		}
+		for o, n := range want[i].Votes {
+			if got.Votes[o] != n {
+				t.Errorf("round %d gave %s %d votes, want %d", i+1, o, got.Votes[o], n)
+			}
+		}
+	}
+}
+
+func TestPairwise(t *testing.T) {
+	prefs := pairwise([]string{"a", "b", "c"}, [][]string{{"a", "b"}, {"b"}, {"c", "a"}})
+	tests := []struct {
+		above, below string
+		want         int
+	}{
+		{above: "a", below: "b", want: 2},
+		{above: "b", below: "a", want: 1},
+		{above: "a", below: "c", want: 1},
+		{above: "c", below: "a", want: 1},
+		{above: "b", below: "c", want: 2},
+		{above: "c", below: "b", want: 1},
+	}
+	for _, tt := range tests {
+		if got := prefs[tt.above][tt.below]; got != tt.want {
+			t.Errorf("%d voters rank %s above %s, want %d", got, tt.above, tt.below, tt.want)
+		}
+	}
+}
+
+func TestSchulze(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  []string
+		rankings [][]string
+		want     string
+	}{
+		{name: "tennessee", options: cities, rankings: tennessee, want: "nashville"},
+		{
+			name:     "condorcet cycle has no winner",
+			options:  []string{"a", "b", "c"},
+			rankings: [][]string{{"a", "b", "c"}, {"b", "c", "a"}, {"c", "a", "b"}},
+			want:     "",
+		},
+		{
+			name:    "cycle broken by the strongest path",
+			options: []string{"a", "b", "c"},
+			rankings: slices.Concat(
+				repeat(5, "a", "b", "c"),
+				repeat(3, "b", "c", "a"),
+				repeat(2, "c", "a", "b"),
+			),
+			want: "a",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := schulze(tt.options, pairwise(tt.options, tt.rankings)); got != tt.want {
+				t.Errorf("schulze() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
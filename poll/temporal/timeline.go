@@ -0,0 +1,24 @@
+package temporal
+
+import (
+	"github.com/brojonat/temporal-examples/convenience"
+)
+
+// The poll records a timeline of its tallies so that the course of the vote
+// can be charted. A point is recorded when the poll opens, whenever a ballot
+// is cast or withdrawn, and when the poll closes. The timeline is subject to
+// the poll's visibility policy, just like the tallies themselves.
+
+const (
+	// query types
+	QueryTypeTimeline = "timeline"
+)
+
+// PollSnapshot is a point on the poll's timeline.
+type PollSnapshot struct {
+	Votes  map[string]float64 `json:"votes"`
+	Voters int                `json:"voters"`
+}
+
+// PollTimeline is the recorded history of a poll.
+type PollTimeline = convenience.Timeline[PollSnapshot]
@@ -0,0 +1,56 @@
+package temporal
+
+import (
+	"errors"
+	"time"
+)
+
+// A poll's visibility policy decides who can see the tallies while the poll
+// runs. Live polls show them to everyone. Polls hidden until close only
+// reveal them once voting is over, and participant-only polls only ever show
+// them to voters who have cast a ballot. Hidden results still report the
+// poll's status and number of voters. The webhook always receives the full
+// results.
+//
+// Once a poll closes, the workflow keeps running for its Retention so the
+// final results can still be queried.
+
+const (
+	// poll visibility policies
+	PollVisibilityLive             = "live"
+	PollVisibilityHiddenUntilClose = "hidden_until_close"
+	PollVisibilityParticipantsOnly = "participants_only"
+
+	// how long the final results are kept when the poll doesn't say
+	DefaultRetention = 24 * time.Hour
+)
+
+// ErrResultsHidden is returned by queries that would give away results the
+// caller isn't allowed to see.
+var ErrResultsHidden = errors.New("the results of this poll are hidden")
+
+// resultsVisible reports whether the voter may see the tallies. The voter is
+// empty for anonymous callers.
+func resultsVisible(r RunPollWFRequest, status string, ballots map[string]PollVote, voter string) bool {
+	switch r.Visibility {
+	case PollVisibilityHiddenUntilClose:
+		return status == PollStatusClosed
+	case PollVisibilityParticipantsOnly:
+		_, ok := ballots[voter]
+		return ok
+	}
+	return true
+}
+
+// hideTallies returns the results without anything that gives away how the
+// vote is going.
+func hideTallies(results PollResult) PollResult {
+	results.Votes = nil
+	results.Averages = nil
+	results.Rounds = nil
+	results.Pairwise = nil
+	results.Winner = ""
+	results.SchulzeWinner = ""
+	results.Hidden = true
+	return results
+}
@@ -1,6 +1,8 @@
 package temporal
 
 import (
+	"fmt"
+	"slices"
 	"time"
 
 	"go.temporal.io/sdk/temporal"
@@ -11,67 +13,400 @@ import (
 // incoming votes on specified poll options. The current state of the poll is
 // queryable. At the end of the poll, the workflow sends the results via HTTP
 // (i.e., webhook) until it receives a 200.
+//
+// A poll with a StartTime in the future is scheduled: it reports when it opens
+// and rejects votes until then. The poll's Duration is measured from
+// StartTime.
+//
+// Every vote is cast by a voter, and the poll keeps one ballot per voter: a
+// new vote replaces the voter's earlier ballot, and a withdrawn vote removes
+// it. The tallies are always recomputed from the ballots, so voting again
+// can't stuff the ballot box.
+//
+// Plurality polls (the default) add up the amount voted for each option.
+// Ranked polls take an ordered ranking from each voter and are decided by
+// instant runoff; see ranked.go. Approval polls let each voter approve any
+// number of options, and score polls have voters rate options from 0 to
+// MaxScore. Quadratic polls have voters spend a budget of voice credits on
+// votes; see quadratic.go.
+//
+// Who can see the tallies before the poll closes, and how long the final
+// results are kept afterwards, is set by the poll's visibility policy; see
+// visibility.go.
 
 const (
+	// keyword search attributes holding the poll's prompt and status, so
+	// polls can be searched for by prompt and open polls told from closed ones
+	SearchAttributePrompt = "PollPrompt"
+	SearchAttributeStatus = "PollStatus"
+
 	// query types
-	QueryTypeState = "state"
+	QueryTypeState   = "state"
+	QueryTypeVersion = "version"
+	QueryTypeMyVote  = "my_vote"
+
+	// update types
+	UpdateTypeVote        = "vote"
+	UpdateTypeWaitVersion = "wait_version"
 
 	// signal types
 	SignalTypeVote = "vote"
+
+	// error type returned when a vote is rejected
+	ErrTypeInvalidVote = "InvalidVote"
+
+	// poll statuses
+	PollStatusScheduled = "scheduled"
+	PollStatusOpen      = "open"
+	PollStatusClosed    = "closed"
+
+	// poll modes
+	PollModePlurality = "plurality"
+	PollModeRanked    = "ranked"
+	PollModeApproval  = "approval"
+	PollModeScore     = "score"
+	PollModeQuadratic = "quadratic"
+
+	// the highest score an option can be given in a score poll
+	MaxScore = 5
+
+	// how long a wait_version update waits for the state to change before
+	// returning the unchanged version
+	VersionWaitTimeout = 15 * time.Second
 )
 
 type PollResult struct {
-	Prompt string             `json:"prompt"`
-	Votes  map[string]float64 `json:"votes"`
+	ID        string             `json:"id"`
+	Prompt    string             `json:"prompt"`
+	Mode      string             `json:"mode"`
+	Options   []string           `json:"options"`
+	Votes     map[string]float64 `json:"votes"`
+	Voters    int                `json:"voters"`
+	Status    string             `json:"status"`
+	StartTime time.Time          `json:"start_time"`
+	EndTime   time.Time          `json:"end_time"`
+	Version   int                `json:"version"`
+
+	// Hidden is set when the visibility policy withholds the tallies from the
+	// caller; closed polls can be queried until RetainUntil
+	Visibility  string    `json:"visibility"`
+	Hidden      bool      `json:"hidden,omitempty"`
+	RetainUntil time.Time `json:"retain_until,omitempty"`
+
+	// score polls report the average score of each option that was scored
+	Averages map[string]float64 `json:"averages,omitempty"`
+
+	// ranked polls report the instant runoff rounds, the pairwise
+	// preferences (Pairwise[a][b] voters rank a above b), and the winners
+	Rounds        []RunoffRound             `json:"rounds,omitempty"`
+	Pairwise      map[string]map[string]int `json:"pairwise,omitempty"`
+	Winner        string                    `json:"winner,omitempty"`
+	SchulzeWinner string                    `json:"schulze_winner,omitempty"`
 }
 
 type RunPollWFRequest struct {
+	ID        string        `json:"id"`
 	StartTime time.Time     `json:"start_time"`
 	Duration  time.Duration `json:"duration"`
 	Prompt    string        `json:"prompt"`
+	Mode      string        `json:"mode"`
 	Options   []string      `json:"options"`
 	Webhook   string        `json:"webhook"`
+
+	// Schulze also finds the Schulze (Condorcet) winner of a ranked poll
+	Schulze bool `json:"schulze"`
+
+	// Credits is each voter's voice credit budget in a quadratic poll
+	Credits int `json:"credits,omitempty"`
+
+	// Visibility decides who can see the tallies before the poll closes, and
+	// Retention is how long the final results can be queried afterwards
+	Visibility string        `json:"visibility"`
+	Retention  time.Duration `json:"retention"`
+}
+
+// Validate checks that the request describes a runnable poll.
+func (r RunPollWFRequest) Validate() error {
+	if r.ID == "" {
+		return fmt.Errorf("must supply a poll ID")
+	}
+	if r.Prompt == "" {
+		return fmt.Errorf("must supply a poll prompt")
+	}
+	if len(r.Options) < 1 {
+		return fmt.Errorf("must supply at least one option")
+	}
+	for i, o := range r.Options {
+		if slices.Contains(r.Options[:i], o) {
+			return fmt.Errorf("duplicate option %q", o)
+		}
+	}
+	switch r.Mode {
+	case "", PollModePlurality, PollModeRanked, PollModeApproval, PollModeScore, PollModeQuadratic:
+	default:
+		return fmt.Errorf("unknown poll mode %q", r.Mode)
+	}
+	if r.Schulze && r.Mode != PollModeRanked {
+		return fmt.Errorf("schulze winners are only found for ranked polls")
+	}
+	if r.Credits < 0 {
+		return fmt.Errorf("credits cannot be negative")
+	}
+	if r.Credits > 0 && r.Mode != PollModeQuadratic {
+		return fmt.Errorf("credit budgets are only used by quadratic polls")
+	}
+	switch r.Visibility {
+	case "", PollVisibilityLive, PollVisibilityHiddenUntilClose, PollVisibilityParticipantsOnly:
+	default:
+		return fmt.Errorf("unknown visibility policy %q", r.Visibility)
+	}
+	if r.Retention < 0 {
+		return fmt.Errorf("retention cannot be negative")
+	}
+	return nil
 }
 
 type PollVote struct {
+	PollID string  `json:"poll_id"`
 	Prompt string  `json:"prompt"`
+	Voter  string  `json:"voter"`
 	Option string  `json:"option"`
 	Amount float64 `json:"amount"`
+
+	// Ranking orders the options from most to least preferred in ranked polls
+	Ranking []string `json:"ranking,omitempty"`
+
+	// Approved lists the options approved of in approval polls
+	Approved []string `json:"approved,omitempty"`
+
+	// Scores rates options from 0 to MaxScore in score polls
+	Scores map[string]int `json:"scores,omitempty"`
+
+	// Allocations casts a number of votes for each option in quadratic polls
+	Allocations map[string]int `json:"allocations,omitempty"`
+
+	// Withdraw removes the voter's ballot instead of casting one
+	Withdraw bool `json:"withdraw,omitempty"`
 }
 
 func RunPollWF(ctx workflow.Context, r RunPollWFRequest) error {
+	if err := r.Validate(); err != nil {
+		return temporal.NewNonRetryableApplicationError(err.Error(), "InvalidRequest", err)
+	}
+	if r.Mode == "" {
+		r.Mode = PollModePlurality
+	}
+	if r.Mode == PollModeQuadratic && r.Credits == 0 {
+		r.Credits = DefaultCredits
+	}
+	if r.Visibility == "" {
+		r.Visibility = PollVisibilityLive
+	}
+	if r.Retention == 0 {
+		r.Retention = DefaultRetention
+	}
+
+	// the poll is measured from StartTime, or from the time the workflow
+	// starts if that's later
+	startTime := workflow.Now(ctx)
+	if r.StartTime.After(startTime) {
+		startTime = r.StartTime
+	}
+
 	// register a handler to return the current poll state
-	results := PollResult{Prompt: r.Prompt, Votes: make(map[string]float64)}
-	for _, o := range r.Options {
-		results.Votes[o] = 0.
+	results := PollResult{
+		ID:      r.ID,
+		Prompt:  r.Prompt,
+		Mode:    r.Mode,
+		Options: r.Options,
+		Status:  PollStatusOpen,
+
+		Visibility: r.Visibility,
+		StartTime:  startTime,
+		EndTime:    startTime.Add(r.Duration),
+	}
+	if workflow.Now(ctx).Before(startTime) {
+		results.Status = PollStatusScheduled
+	}
+	ballots := map[string]PollVote{}
+	recount(&results, r, ballots)
+
+	// snapshot records the current tallies on the timeline; recount builds
+	// a new map every time, so the recorded tallies are never changed
+	var timeline PollTimeline
+	snapshot := func() {
+		timeline.Record(workflow.Now(ctx), PollSnapshot{Votes: results.Votes, Voters: results.Voters})
+	}
+	if results.Status == PollStatusOpen {
+		snapshot()
 	}
-	err := workflow.SetQueryHandler(ctx, QueryTypeState, func() (PollResult, error) {
+
+	err := workflow.SetQueryHandler(ctx, QueryTypeState, func(voter string) (PollResult, error) {
+		if !resultsVisible(r, results.Status, ballots, voter) {
+			return hideTallies(results), nil
+		}
 		return results, nil
 	})
 	if err != nil {
 		return err
 	}
 
+	// register a handler to return the timeline of the tallies
+	err = workflow.SetQueryHandler(ctx, QueryTypeTimeline, func(voter string) (PollTimeline, error) {
+		if !resultsVisible(r, results.Status, ballots, voter) {
+			return PollTimeline{}, ErrResultsHidden
+		}
+		return timeline, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// register a handler to return the state version, which changes whenever
+	// the state does
+	err = workflow.SetQueryHandler(ctx, QueryTypeVersion, func() (int, error) {
+		return results.Version, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// watchers long-poll for changes: the update blocks until the version
+	// moves past the one they last saw, or VersionWaitTimeout passes, and
+	// returns the current version. Waits end early once the workflow is
+	// finishing so they never hold it open.
+	finishing := false
+	err = workflow.SetUpdateHandler(ctx, UpdateTypeWaitVersion, func(ctx workflow.Context, seen int) (int, error) {
+		_, err := workflow.AwaitWithTimeout(ctx, VersionWaitTimeout, func() bool {
+			return results.Version > seen || finishing
+		})
+		return results.Version, err
+	})
+	if err != nil {
+		return err
+	}
+
+	// register a handler to return the ballot a voter cast; ballots give the
+	// results away, so they're only shown to voters who can see the results
+	err = workflow.SetQueryHandler(ctx, QueryTypeMyVote, func(voter string) (PollVote, error) {
+		if !resultsVisible(r, results.Status, ballots, voter) {
+			return PollVote{}, ErrResultsHidden
+		}
+		ballot, ok := ballots[voter]
+		if !ok {
+			return PollVote{}, fmt.Errorf("%s has not voted in this poll", voter)
+		}
+		return ballot, nil
+	})
+	if err != nil {
+		return err
+	}
+
+	// register a handler to return how many voice credits a voter has left
+	err = workflow.SetQueryHandler(ctx, QueryTypeCredits, func(voter string) (VoterCredits, error) {
+		if r.Mode != PollModeQuadratic {
+			return VoterCredits{}, fmt.Errorf("voice credits are only used by quadratic polls")
+		}
+		c := VoterCredits{Voter: voter, Budget: r.Credits}
+		if ballot, ok := ballots[voter]; ok {
+			c.Spent = QuadraticCost(allocationsOf(ballot))
+		}
+		c.Remaining = c.Budget - c.Spent
+		return c, nil
+	})
+	if err != nil {
+		return err
+	}
+
 	// initialization for main selector loop
 	doLoop := true
-	var signal PollVote
 	selector := workflow.NewSelector(ctx)
 
-	// receive poll votes
+	// checkVote returns an error if the vote can't be cast; voters can only
+	// withdraw a ballot they've cast
+	checkVote := func(vote PollVote) error {
+		if err := checkBallot(r, vote); err != nil {
+			return err
+		}
+		if _, ok := ballots[vote.Voter]; vote.Withdraw && !ok {
+			return fmt.Errorf("%s has not voted in this poll", vote.Voter)
+		}
+		return nil
+	}
+
+	// castVote replaces (or withdraws) the voter's ballot and recounts
+	castVote := func(vote PollVote) {
+		if vote.Withdraw {
+			delete(ballots, vote.Voter)
+		} else {
+			ballots[vote.Voter] = vote
+		}
+		recount(&results, r, ballots)
+		results.Version++
+		snapshot()
+	}
+
+	// receive poll votes through an update so that rejected votes are
+	// reported back to the voter; votes are only taken while the poll is open
+	err = workflow.SetUpdateHandlerWithOptions(
+		ctx,
+		UpdateTypeVote,
+		func(ctx workflow.Context, vote PollVote) error {
+			castVote(vote)
+			return nil
+		},
+		workflow.UpdateHandlerOptions{
+			Validator: func(ctx workflow.Context, vote PollVote) error {
+				switch results.Status {
+				case PollStatusScheduled:
+					return rejectVote(r, fmt.Errorf("poll opens at %s", startTime.Format(time.RFC3339)))
+				case PollStatusClosed:
+					return rejectVote(r, fmt.Errorf("poll is closed"))
+				}
+				if err := checkVote(vote); err != nil {
+					return rejectVote(r, err)
+				}
+				return nil
+			},
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	// votes may also be signaled; signaled votes that can't be cast are
+	// dropped since there's no one to report them to
 	bidChan := workflow.GetSignalChannel(ctx, SignalTypeVote)
 	selector.AddReceive(bidChan, func(c workflow.ReceiveChannel, more bool) {
+		var signal PollVote
 		c.Receive(ctx, &signal)
-		if _, ok := results.Votes[signal.Option]; !ok {
+		if err := checkVote(signal); err != nil {
+			workflow.GetLogger(ctx).Warn("dropping vote", "voter", signal.Voter, "error", err)
 			return
 		}
-		results.Votes[signal.Option] += signal.Amount
+		castVote(signal)
 	})
 
+	// wait for a scheduled poll to open; votes sent in the meantime stay
+	// buffered on the channel until the loop starts
+	if results.Status == PollStatusScheduled {
+		err = workflow.Sleep(ctx, startTime.Sub(workflow.Now(ctx)))
+		if err != nil {
+			return err
+		}
+		results.Status = PollStatusOpen
+		results.Version++
+		snapshot()
+		if err = upsertStatus(ctx, results.Status); err != nil {
+			return err
+		}
+	}
+
 	// receive poll over; uses a separate goroutine that will block until the
 	// poll is over before sending on the pollOverChan.
 	pollOverChan := workflow.NewChannel(ctx)
 	workflow.Go(ctx, func(ictx workflow.Context) {
-		wait := time.Until(time.Now().Add(r.Duration))
+		wait := results.EndTime.Sub(workflow.Now(ictx))
 		workflow.AwaitWithTimeout(ictx, wait, func() bool { return false })
 		pollOverChan.Send(ictx, nil)
 	})
@@ -84,6 +419,14 @@ func RunPollWF(ctx workflow.Context, r RunPollWFRequest) error {
 	for doLoop {
 		selector.Select(ctx)
 	}
+	results.Status = PollStatusClosed
+	results.RetainUntil = workflow.Now(ctx).Add(r.Retention)
+	results.Version++
+	snapshot()
+	finishing = true
+	if err = upsertStatus(ctx, results.Status); err != nil {
+		return err
+	}
 
 	// send the webhook with the results
 	rp := temporal.RetryPolicy{
@@ -99,5 +442,20 @@ func RunPollWF(ctx workflow.Context, r RunPollWFRequest) error {
 	}
 	ctx = workflow.WithActivityOptions(ctx, aopts)
 	err = workflow.ExecuteActivity(ctx, RunPollCompleteWebhook, r.Webhook, results).Get(ctx, nil)
-	return err
+	if err != nil {
+		return err
+	}
+
+	// keep the final results queryable until the retention window is over
+	return workflow.Sleep(ctx, results.RetainUntil.Sub(workflow.Now(ctx)))
+}
+
+// upsertStatus updates the poll's PollStatus search attribute. Polls started
+// without the attribute (i.e., outside the poll server) are left alone.
+func upsertStatus(ctx workflow.Context, status string) error {
+	key := temporal.NewSearchAttributeKeyKeyword(SearchAttributeStatus)
+	if _, ok := workflow.GetTypedSearchAttributes(ctx).GetKeyword(key); !ok {
+		return nil
+	}
+	return workflow.UpsertTypedSearchAttributes(ctx, key.ValueSet(status))
 }
@@ -0,0 +1,549 @@
+package temporal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+	"go.temporal.io/sdk/temporal"
+	"go.temporal.io/sdk/testsuite"
+)
+
+type PollWorkflowSuite struct {
+	suite.Suite
+	testsuite.WorkflowTestSuite
+
+	env   *testsuite.TestWorkflowEnvironment
+	start time.Time
+
+	// result is what the poll sent to its webhook
+	result PollResult
+}
+
+func TestPollWorkflowSuite(t *testing.T) {
+	suite.Run(t, new(PollWorkflowSuite))
+}
+
+func (s *PollWorkflowSuite) SetupTest() {
+	s.env = s.NewTestWorkflowEnvironment()
+	s.start = s.env.Now()
+	s.result = PollResult{}
+	s.env.OnActivity(RunPollCompleteWebhook, mock.Anything, mock.Anything, mock.Anything).Return(
+		func(ctx context.Context, endpoint string, result PollResult) error {
+			s.result = result
+			return nil
+		}).Maybe()
+}
+
+func (s *PollWorkflowSuite) AfterTest(suiteName, testName string) {
+	s.env.AssertExpectations(s.T())
+}
+
+// voteAt sends a vote once the delay has passed (in workflow time).
+func (s *PollWorkflowSuite) voteAt(delay time.Duration, vote PollVote) {
+	s.env.RegisterDelayedCallback(func() {
+		s.env.SignalWorkflow(SignalTypeVote, vote)
+	}, delay)
+}
+
+// stateAt queries the poll state once the delay has passed.
+func (s *PollWorkflowSuite) stateAt(delay time.Duration) *PollResult {
+	state := &PollResult{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeState)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(state))
+	}, delay)
+	return state
+}
+
+// updateOutcome is what became of an update; it receives the update's
+// callbacks.
+type updateOutcome struct {
+	value interface{}
+	err   error
+}
+
+func (o *updateOutcome) Accept() {}
+
+func (o *updateOutcome) Reject(err error) {
+	o.err = err
+}
+
+func (o *updateOutcome) Complete(result interface{}, err error) {
+	o.value, o.err = result, err
+}
+
+// voteUpdateAt casts a vote through an update once the delay has passed.
+func (s *PollWorkflowSuite) voteUpdateAt(delay time.Duration, vote PollVote) *updateOutcome {
+	outcome := &updateOutcome{}
+	s.env.RegisterDelayedCallback(func() {
+		s.env.UpdateWorkflow(UpdateTypeVote, vote.Voter+"-"+delay.String(), outcome, vote)
+	}, delay)
+	return outcome
+}
+
+// waitVersionAt sends a wait_version update for the version the poll is at
+// once the delay has passed; seen is set to that version.
+func (s *PollWorkflowSuite) waitVersionAt(delay time.Duration, seen *int) *updateOutcome {
+	outcome := &updateOutcome{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeVersion)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(seen))
+		s.env.UpdateWorkflow(UpdateTypeWaitVersion, "wait-"+delay.String(), outcome, *seen)
+	}, delay)
+	return outcome
+}
+
+// stateForAt queries the poll state on behalf of a voter once the delay has
+// passed.
+func (s *PollWorkflowSuite) stateForAt(delay time.Duration, voter string) *PollResult {
+	state := &PollResult{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeState, voter)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(state))
+	}, delay)
+	return state
+}
+
+// run runs the poll to completion and returns the result it sent.
+func (s *PollWorkflowSuite) run(r RunPollWFRequest) PollResult {
+	s.env.ExecuteWorkflow(RunPollWF, r)
+	s.Require().True(s.env.IsWorkflowCompleted())
+	s.Require().NoError(s.env.GetWorkflowError())
+	return s.result
+}
+
+func (s *PollWorkflowSuite) Test_ScheduledStart() {
+	s.voteAt(10*time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	scheduled := s.stateAt(20 * time.Minute)
+	s.voteAt(70*time.Minute, PollVote{Voter: "bob", Option: "blue", Amount: 2})
+	open := s.stateAt(80 * time.Minute)
+
+	result := s.run(RunPollWFRequest{
+		ID:        "colors",
+		StartTime: s.start.Add(time.Hour),
+		Duration:  time.Hour,
+		Prompt:    "favorite color?",
+		Options:   []string{"red", "blue"},
+		Webhook:   "http://localhost:8080/webhook",
+	})
+
+	s.Equal(PollStatusScheduled, scheduled.Status)
+	s.Zero(scheduled.Votes["red"])
+	s.WithinDuration(s.start.Add(time.Hour), scheduled.StartTime, 0)
+	s.WithinDuration(s.start.Add(2*time.Hour), scheduled.EndTime, 0)
+	s.Equal(PollStatusOpen, open.Status)
+	s.Equal(PollStatusClosed, result.Status)
+	s.Equal(map[string]float64{"red": 1, "blue": 2}, result.Votes)
+}
+
+func (s *PollWorkflowSuite) Test_WaitVersionReturnsOnChange() {
+	var seen int
+	wait := s.waitVersionAt(time.Minute, &seen)
+	var waiting bool
+	s.env.RegisterDelayedCallback(func() { waiting = wait.value == nil }, time.Minute+5*time.Second)
+	s.voteAt(time.Minute+10*time.Second, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	var changed interface{}
+	s.env.RegisterDelayedCallback(func() { changed = wait.value }, time.Minute+11*time.Second)
+
+	s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.True(waiting)
+	s.NoError(wait.err)
+	s.Require().IsType(0, changed)
+	s.Greater(changed.(int), seen)
+}
+
+func (s *PollWorkflowSuite) Test_WaitVersionTimesOut() {
+	var seen int
+	wait := s.waitVersionAt(time.Minute, &seen)
+	var waiting bool
+	s.env.RegisterDelayedCallback(func() { waiting = wait.value == nil }, time.Minute+VersionWaitTimeout-time.Second)
+	var unchanged interface{}
+	s.env.RegisterDelayedCallback(func() { unchanged = wait.value }, time.Minute+VersionWaitTimeout+time.Second)
+
+	s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.True(waiting)
+	s.NoError(wait.err)
+	s.Equal(seen, unchanged)
+}
+
+// myVoteAt queries a voter's ballot once the delay has passed.
+func (s *PollWorkflowSuite) myVoteAt(delay time.Duration, voter string) *PollVote {
+	ballot := &PollVote{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeMyVote, voter)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(ballot))
+	}, delay)
+	return ballot
+}
+
+func (s *PollWorkflowSuite) Test_RevoteReplacesBallot() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	s.voteAt(2*time.Minute, PollVote{Voter: "bob", Option: "blue", Amount: 2})
+	first := s.stateAt(3 * time.Minute)
+	s.voteAt(4*time.Minute, PollVote{Voter: "alice", Option: "blue", Amount: 3})
+	ballot := s.myVoteAt(5*time.Minute, "alice")
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Equal(map[string]float64{"red": 1, "blue": 2}, first.Votes)
+	s.Equal("blue", ballot.Option)
+	s.Equal(3., ballot.Amount)
+	s.Equal(map[string]float64{"red": 0, "blue": 5}, result.Votes)
+	s.Equal(2, result.Voters)
+}
+
+func (s *PollWorkflowSuite) Test_WithdrawRemovesBallot() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	s.voteAt(2*time.Minute, PollVote{Voter: "bob", Option: "blue", Amount: 2})
+	s.voteAt(3*time.Minute, PollVote{Voter: "alice", Withdraw: true})
+	s.voteAt(4*time.Minute, PollVote{Voter: "carol", Withdraw: true})
+	s.voteAt(5*time.Minute, PollVote{Option: "red", Amount: 1})
+	var missing error
+	s.env.RegisterDelayedCallback(func() {
+		_, missing = s.env.QueryWorkflow(QueryTypeMyVote, "alice")
+	}, 6*time.Minute)
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Error(missing)
+	s.Equal(map[string]float64{"red": 0, "blue": 2}, result.Votes)
+	s.Equal(1, result.Voters)
+}
+
+func (s *PollWorkflowSuite) Test_RankedPollRunsOff() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Ranking: []string{"a", "c"}})
+	s.voteAt(time.Minute, PollVote{Voter: "bob", Ranking: []string{"b", "a"}})
+	s.voteAt(time.Minute, PollVote{Voter: "carol", Ranking: []string{"c"}})
+	s.voteAt(time.Minute, PollVote{Voter: "dave", Ranking: []string{"c"}})
+	s.voteAt(time.Minute, PollVote{Voter: "erin", Option: "a"})
+	s.voteAt(time.Minute, PollVote{Voter: "frank", Ranking: []string{"a", "a"}})
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "which letter?",
+		Mode:     PollModeRanked,
+		Options:  []string{"a", "b", "c"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Equal(map[string]float64{"a": 2, "b": 1, "c": 2}, result.Votes)
+	s.Equal(5, result.Voters)
+	s.Len(result.Rounds, 2)
+	s.Equal("a", result.Winner)
+}
+
+func (s *PollWorkflowSuite) Test_ApprovalPollCountsApprovals() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Approved: []string{"red", "blue"}})
+	s.voteAt(time.Minute, PollVote{Voter: "bob", Approved: []string{"blue"}})
+	s.voteAt(time.Minute, PollVote{Voter: "carol", Approved: []string{"green", "blue"}})
+	s.voteAt(2*time.Minute, PollVote{Voter: "carol", Approved: []string{"green"}})
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Mode:     PollModeApproval,
+		Options:  []string{"red", "green", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Equal(map[string]float64{"red": 1, "green": 1, "blue": 2}, result.Votes)
+	s.Equal(3, result.Voters)
+}
+
+func (s *PollWorkflowSuite) Test_ScorePollAveragesScores() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Scores: map[string]int{"red": 5, "blue": 2}})
+	s.voteAt(time.Minute, PollVote{Voter: "bob", Scores: map[string]int{"red": 2}})
+	s.voteAt(time.Minute, PollVote{Voter: "carol", Scores: map[string]int{"red": MaxScore + 1}})
+	s.voteAt(time.Minute, PollVote{Voter: "dave", Scores: map[string]int{"blue": -1}})
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Mode:     PollModeScore,
+		Options:  []string{"red", "green", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Equal(map[string]float64{"red": 7, "green": 0, "blue": 2}, result.Votes)
+	s.Equal(map[string]float64{"red": 3.5, "blue": 2}, result.Averages)
+	s.Equal(2, result.Voters)
+}
+
+// creditsAt queries a voter's voice credits once the delay has passed.
+func (s *PollWorkflowSuite) creditsAt(delay time.Duration, voter string) *VoterCredits {
+	credits := &VoterCredits{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeCredits, voter)
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(credits))
+	}, delay)
+	return credits
+}
+
+func (s *PollWorkflowSuite) Test_QuadraticPollEnforcesBudget() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Allocations: map[string]int{"red": 3, "blue": 4}})
+	s.voteAt(time.Minute, PollVote{Voter: "bob", Allocations: map[string]int{"red": 3, "blue": 3}})
+	s.voteAt(time.Minute, PollVote{Voter: "carol", Option: "blue", Amount: 5})
+	s.voteAt(time.Minute, PollVote{Voter: "dave", Option: "red", Amount: 2})
+	alice := s.creditsAt(2*time.Minute, "alice")
+	bob := s.creditsAt(2*time.Minute, "bob")
+	dave := s.creditsAt(2*time.Minute, "dave")
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "roadmap?",
+		Mode:     PollModeQuadratic,
+		Credits:  20,
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Equal(map[string]float64{"red": 5, "blue": 3}, result.Votes)
+	s.Equal(2, result.Voters)
+	s.Equal(VoterCredits{Voter: "alice", Budget: 20, Spent: 0, Remaining: 20}, *alice)
+	s.Equal(VoterCredits{Voter: "bob", Budget: 20, Spent: 18, Remaining: 2}, *bob)
+	s.Equal(VoterCredits{Voter: "dave", Budget: 20, Spent: 4, Remaining: 16}, *dave)
+}
+
+func (s *PollWorkflowSuite) Test_VoteUpdateRejectsBadVotes() {
+	cast := s.voteUpdateAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	unknown := s.voteUpdateAt(2*time.Minute, PollVote{Voter: "bob", Option: "pink", Amount: 1})
+	negative := s.voteUpdateAt(3*time.Minute, PollVote{Voter: "carol", Option: "blue", Amount: -2})
+
+	result := s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.NoError(cast.err)
+	for _, rejected := range []*updateOutcome{unknown, negative} {
+		var appErr *temporal.ApplicationError
+		s.Require().True(errors.As(rejected.err, &appErr))
+		s.Equal(ErrTypeInvalidVote, appErr.Type())
+		var rejection VoteRejection
+		s.Require().NoError(appErr.Details(&rejection))
+		s.Equal([]string{"red", "blue"}, rejection.ValidOptions)
+	}
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, result.Votes)
+	s.Equal(1, result.Voters)
+}
+
+func (s *PollWorkflowSuite) Test_StatusSearchAttributeFollowsThePoll() {
+	prompt := temporal.NewSearchAttributeKeyKeyword(SearchAttributePrompt)
+	status := temporal.NewSearchAttributeKeyKeyword(SearchAttributeStatus)
+	s.Require().NoError(s.env.SetTypedSearchAttributesOnStart(temporal.NewSearchAttributes(
+		prompt.ValueSet("favorite color?"),
+		status.ValueSet(PollStatusScheduled),
+	)))
+	opened := s.env.OnUpsertTypedSearchAttributes(temporal.NewSearchAttributes(status.ValueSet(PollStatusOpen))).Return(nil).Once()
+	s.env.OnUpsertTypedSearchAttributes(temporal.NewSearchAttributes(status.ValueSet(PollStatusClosed))).Return(nil).Once().NotBefore(opened)
+
+	s.run(RunPollWFRequest{
+		ID:        "colors",
+		StartTime: s.start.Add(time.Hour),
+		Duration:  time.Hour,
+		Prompt:    "favorite color?",
+		Options:   []string{"red", "blue"},
+		Webhook:   "http://localhost:8080/webhook",
+	})
+}
+
+func (s *PollWorkflowSuite) Test_StatusSearchAttributeNeedsToBeSetAtStart() {
+	s.env.OnUpsertTypedSearchAttributes(mock.Anything).Return(nil).Never()
+
+	s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+}
+
+func (s *PollWorkflowSuite) Test_LivePollShowsTallies() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	anonymous := s.stateForAt(2*time.Minute, "")
+	ballot := s.myVoteAt(2*time.Minute, "alice")
+
+	s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.False(anonymous.Hidden)
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, anonymous.Votes)
+	s.Equal("red", ballot.Option)
+}
+
+func (s *PollWorkflowSuite) Test_HiddenUntilCloseHidesTallies() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	anonymous := s.stateForAt(2*time.Minute, "")
+	voter := s.stateForAt(2*time.Minute, "alice")
+	var hiddenBallot error
+	s.env.RegisterDelayedCallback(func() {
+		_, hiddenBallot = s.env.QueryWorkflow(QueryTypeMyVote, "alice")
+	}, 2*time.Minute)
+	closed := s.stateForAt(90*time.Minute, "")
+	ballot := s.myVoteAt(90*time.Minute, "alice")
+
+	result := s.run(RunPollWFRequest{
+		ID:         "colors",
+		Duration:   time.Hour,
+		Prompt:     "favorite color?",
+		Options:    []string{"red", "blue"},
+		Webhook:    "http://localhost:8080/webhook",
+		Visibility: PollVisibilityHiddenUntilClose,
+	})
+
+	for _, hidden := range []*PollResult{anonymous, voter} {
+		s.True(hidden.Hidden)
+		s.Nil(hidden.Votes)
+		s.Equal(1, hidden.Voters)
+	}
+	s.ErrorContains(hiddenBallot, ErrResultsHidden.Error())
+	s.False(closed.Hidden)
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, closed.Votes)
+	s.Equal("red", ballot.Option)
+	s.False(result.Hidden)
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, result.Votes)
+}
+
+func (s *PollWorkflowSuite) Test_ParticipantsOnlyShowsVoters() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	anonymous := s.stateForAt(2*time.Minute, "")
+	participant := s.stateForAt(2*time.Minute, "alice")
+	outsider := s.stateForAt(2*time.Minute, "bob")
+	ballot := s.myVoteAt(2*time.Minute, "alice")
+	var hiddenBallot error
+	s.env.RegisterDelayedCallback(func() {
+		_, hiddenBallot = s.env.QueryWorkflow(QueryTypeMyVote, "bob")
+	}, 2*time.Minute)
+	closed := s.stateForAt(90*time.Minute, "bob")
+
+	result := s.run(RunPollWFRequest{
+		ID:         "colors",
+		Duration:   time.Hour,
+		Prompt:     "favorite color?",
+		Options:    []string{"red", "blue"},
+		Webhook:    "http://localhost:8080/webhook",
+		Visibility: PollVisibilityParticipantsOnly,
+	})
+
+	s.True(anonymous.Hidden)
+	s.True(outsider.Hidden)
+	s.Nil(outsider.Votes)
+	s.False(participant.Hidden)
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, participant.Votes)
+	s.Equal("red", ballot.Option)
+	s.ErrorContains(hiddenBallot, ErrResultsHidden.Error())
+	s.True(closed.Hidden, "participant-only results stay hidden after the poll closes")
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, result.Votes, "the webhook gets the full results")
+}
+
+func (s *PollWorkflowSuite) Test_ResultsKeptForRetention() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	retained := s.stateForAt(2*time.Hour+30*time.Minute, "")
+
+	s.run(RunPollWFRequest{
+		ID:        "colors",
+		Duration:  time.Hour,
+		Prompt:    "favorite color?",
+		Options:   []string{"red", "blue"},
+		Webhook:   "http://localhost:8080/webhook",
+		Retention: 2 * time.Hour,
+	})
+
+	s.Equal(PollStatusClosed, s.result.Status)
+	s.WithinDuration(s.start.Add(3*time.Hour), s.result.RetainUntil, 0)
+	s.Equal(PollStatusClosed, retained.Status)
+	s.Equal(map[string]float64{"red": 1, "blue": 0}, retained.Votes)
+	s.WithinDuration(s.start.Add(3*time.Hour), s.env.Now(), 0)
+}
+
+func (s *PollWorkflowSuite) Test_TimelineChartsTheTallies() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	s.voteAt(2*time.Minute, PollVote{Voter: "bob", Option: "blue", Amount: 2})
+	timeline := &PollTimeline{}
+	s.env.RegisterDelayedCallback(func() {
+		v, err := s.env.QueryWorkflow(QueryTypeTimeline, "")
+		s.Require().NoError(err)
+		s.Require().NoError(v.Get(timeline))
+	}, 3*time.Minute)
+
+	s.run(RunPollWFRequest{
+		ID:       "colors",
+		Duration: time.Hour,
+		Prompt:   "favorite color?",
+		Options:  []string{"red", "blue"},
+		Webhook:  "http://localhost:8080/webhook",
+	})
+
+	s.Require().Len(timeline.Points, 3)
+	s.Equal(PollSnapshot{Votes: map[string]float64{"red": 0, "blue": 0}}, timeline.Points[0].Value)
+	s.Equal(PollSnapshot{Votes: map[string]float64{"red": 1, "blue": 0}, Voters: 1}, timeline.Points[1].Value)
+	s.WithinDuration(s.start.Add(2*time.Minute), timeline.Points[2].Time, 0)
+	s.Equal(PollSnapshot{Votes: map[string]float64{"red": 1, "blue": 2}, Voters: 2}, timeline.Points[2].Value)
+}
+
+func (s *PollWorkflowSuite) Test_TimelineFollowsVisibility() {
+	s.voteAt(time.Minute, PollVote{Voter: "alice", Option: "red", Amount: 1})
+	var hidden error
+	s.env.RegisterDelayedCallback(func() {
+		_, hidden = s.env.QueryWorkflow(QueryTypeTimeline, "alice")
+	}, 2*time.Minute)
+
+	s.run(RunPollWFRequest{
+		ID:         "colors",
+		Duration:   time.Hour,
+		Prompt:     "favorite color?",
+		Options:    []string{"red", "blue"},
+		Webhook:    "http://localhost:8080/webhook",
+		Visibility: PollVisibilityHiddenUntilClose,
+	})
+
+	s.ErrorContains(hidden, ErrResultsHidden.Error())
+}
@@ -29,12 +29,19 @@ func RunWorker(ctx context.Context, l *slog.Logger, thp string) error {
 	// register workflows
 	w := worker.New(c, TaskQueue, worker.Options{})
 	w.RegisterWorkflow(auction.RunAuctionWF)
+	w.RegisterWorkflow(auction.RunCatalogWF)
+	w.RegisterWorkflow(auction.RunBidderWF)
 	w.RegisterWorkflow(poll.RunPollWF)
 	w.RegisterWorkflow(dms.RunDMSWF)
 	w.RegisterWorkflow(heart.RunHeartWF)
 
 	// register activities
 	w.RegisterActivity(auction.RunAuctionCompleteWebhook)
+	w.RegisterActivity(auction.RunAuctionCancelledWebhook)
+	w.RegisterActivity(auction.RunAuctionOutbidWebhook)
+	w.RegisterActivity(auction.RunCatalogReportWebhook)
+	// no payment processor is wired up here, so sold auctions settle as unpaid
+	w.RegisterActivity(&auction.Activities{Client: c})
 	w.RegisterActivity(poll.RunPollCompleteWebhook)
 	w.RegisterActivity(dms.RunDMSTimeoutWebhook)
 	w.RegisterActivity(heart.RunHeartActivity)